@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterCronicleInstanceSpec defines how the operator reaches a Cronicle cluster shared across namespaces
+type ClusterCronicleInstanceSpec struct {
+	CronicleInstanceSpec `json:",inline"`
+
+	// Namespace holds the Service referenced by serviceRef and the API key Secret.
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+
+	// AllowedNamespaces selects the namespaces whose CronicleEvents may use this instance.
+	// An empty selector allows every namespace, a missing one allows none.
+	AllowedNamespaces *metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+}
+
+// ClusterCronicleInstanceStatus defines the observed state of ClusterCronicleInstance
+type ClusterCronicleInstanceStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.serviceRef.name`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.namespace`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterCronicleInstance is the Schema for the clustercronicleinstances API
+type ClusterCronicleInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterCronicleInstanceSpec   `json:"spec,omitempty"`
+	Status ClusterCronicleInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterCronicleInstanceList contains a list of ClusterCronicleInstance
+type ClusterCronicleInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterCronicleInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterCronicleInstance{}, &ClusterCronicleInstanceList{})
+}
@@ -18,16 +18,20 @@ package v1
 
 import (
 	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // CronicleEventSpec defines the desired state of CronicleEvent
+// +kubebuilder:validation:XValidation:rule="!has(self.schedule) || !has(self.timing) || !(has(self.timing.minutes) || has(self.timing.hours) || has(self.timing.days) || has(self.timing.months) || has(self.timing.weekdays) || has(self.timing.years))",message="timing and schedule are mutually exclusive"
 type CronicleEventSpec struct {
 	// +kubebuilder:default=0
 	CatchUp int `json:"catchUp,omitempty"`
 
-	// +kubebuilder:validation:Required
-	Category string `json:"category"`
+	// Category is the ID of the category of the event in Cronicle
+	Category string `json:"category,omitempty"`
+	// CategoryName is the title of the category, resolved to its ID in the Cronicle instance
+	CategoryName string `json:"categoryName,omitempty"`
 
 	CpuLimit   int `json:"cpuLimit,omitempty"`
 	CpuSustain int `json:"cpuSustain,omitempty"`
@@ -52,12 +56,31 @@ type CronicleEventSpec struct {
 	// +kubebuilder:default=""
 	NotifySuccess string `json:"notifySuccess,omitempty"`
 
+	// Params are the parameters of the plugin, such as the script of the Shell Plugin
 	// +kubebuilder:validation:Required
-	Params cronicle_client.CronicleParams `json:"params"`
+	Params EventParams `json:"params"`
 
-	// +kubebuilder:validation:Required
-	// +kubebuilder:default="shellplug"
-	Plugin string `json:"plugin"`
+	// TemplateValues turns on the rendering of the script and of the string parameters as Go
+	// templates, with the values available as .Values. Templates can also use the .Name,
+	// .Namespace, .Labels and .Annotations of the CronicleEvent and the .InstanceURL of its Cronicle.
+	TemplateValues map[string]string `json:"templateValues,omitempty"`
+	// TemplateValuesFrom adds the keys of ConfigMaps to the template values and turns on the
+	// rendering as well. Later ConfigMaps override earlier ones, TemplateValues override them all.
+	TemplateValuesFrom []TemplateValuesSource `json:"templateValuesFrom,omitempty"`
+
+	// Env are passed to the jobs of the event as environment variables. Cronicle hands plugin
+	// parameters to jobs as environment variables, so they are added to the parameters sent to
+	// Cronicle and must not clash with them. Values read from Secrets are never written to the
+	// status of the CronicleEvent, and the event is updated when the Secrets change.
+	// +listType=map
+	// +listMapKey=name
+	Env []EnvVar `json:"env,omitempty"`
+
+	// Plugin is the ID of the plugin running the jobs of the event. The Shell Plugin is used
+	// when neither Plugin nor PluginName is set.
+	Plugin string `json:"plugin,omitempty"`
+	// PluginName is the title of the plugin, resolved to its ID in the Cronicle instance
+	PluginName string `json:"pluginName,omitempty"`
 
 	// +kubebuilder:default=0
 	Retries int `json:"retries,omitempty"`
@@ -65,8 +88,12 @@ type CronicleEventSpec struct {
 	// +kubebuilder:default=30
 	RetryDelay int `json:"retryDelay,omitempty"`
 
-	// +kubebuilder:validation:Required
-	Target string `json:"target"`
+	// Target is the ID of the server group or the hostname of the server running the jobs
+	Target string `json:"target,omitempty"`
+	// TargetGroupName is the title of a server group, resolved to its ID in the Cronicle instance
+	TargetGroupName string `json:"targetGroupName,omitempty"`
+	// TargetHostname is the hostname of the server running the jobs
+	TargetHostname string `json:"targetHostname,omitempty"`
 
 	// +kubebuilder:default=36000
 	Timeout int `json:"timeout,omitempty"`
@@ -75,34 +102,273 @@ type CronicleEventSpec struct {
 	// +kubebuilder:default="Europe/Istanbul"
 	Timezone string `json:"timezone"`
 
-	// +kubebuilder:validation:Required
+	// Timing lists the minutes, hours, days, months, weekdays and years the event runs at.
+	// An empty timing runs every minute. Leave it empty when Schedule is set.
 	Timing cronicle_client.CronicleTiming `json:"timing,omitempty"`
 
+	// Schedule is a five field cron expression such as "*/5 * * * *", or one of the macros
+	// @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly. It is converted
+	// to the Cronicle timing of the event.
+	Schedule string `json:"schedule,omitempty"`
+
 	// +kubebuilder:validation:Required
 	Title     string `json:"title"`
 	Algorithm string `json:"algorithm,omitempty"`
 
 	// +kubebuilder:default=""
-	WebHook          string                `json:"webhook,omitempty"`
+	WebHook string `json:"webhook,omitempty"`
+
+	// DriftPolicy decides what happens when the event in Cronicle no longer matches the spec,
+	// for example after an edit in the Cronicle UI. Enforce overwrites the change or recreates
+	// a missing event, Report only surfaces it in the Drifted condition and Ignore skips the check.
+	// +kubebuilder:default="Enforce"
+	// +kubebuilder:validation:Enum=Enforce;Report;Ignore
+	DriftPolicy string `json:"driftPolicy,omitempty"`
+
+	// DeletionPolicy decides what happens to the event in Cronicle when the CronicleEvent is
+	// deleted. Delete waits for running jobs and deletes it, Disable leaves it disabled and
+	// Orphan leaves it untouched. Events that are kept are no longer marked as owned by the operator.
+	// A CronicleEvent created again later gets a new event, unless it names the kept one in the
+	// cronicle.net/adopt-event-id annotation.
+	// +kubebuilder:default="Delete"
+	// +kubebuilder:validation:Enum=Delete;Disable;Orphan
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// DeletionGracePeriod is how long the deletion of the event waits for its running jobs
+	// before AbortRunningJobsOnDelete aborts them. It is counted from the deletion request.
+	// When unset there is no grace period and the running jobs are aborted right away.
+	DeletionGracePeriod *metav1.Duration `json:"deletionGracePeriod,omitempty"`
+	// AbortRunningJobsOnDelete aborts the jobs still running after DeletionGracePeriod so that
+	// the deletion can proceed. Without it the deletion waits until the jobs finish. Set
+	// DeletionGracePeriod as well to give the jobs time to finish first.
+	AbortRunningJobsOnDelete bool `json:"abortRunningJobsOnDelete,omitempty"`
+
+	// MissedRunGracePeriod turns on missed run detection. A scheduled run that has not
+	// succeeded this long after its scheduled time sets the MissedSchedule condition. It
+	// should cover the usual run time of the job.
+	MissedRunGracePeriod *metav1.Duration `json:"missedRunGracePeriod,omitempty"`
+
+	// InstanceRef names the CronicleInstance or ClusterCronicleInstance the event is created on.
+	InstanceRef *InstanceReference `json:"instanceRef,omitempty"`
+
+	// Deprecated: use InstanceRef. The first Service matching the selector is used
+	// and the API key is read from the CRONICLE_API_KEY environment variable.
 	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
 }
 
+// EnvVar is an environment variable of the jobs of an event
+// +kubebuilder:validation:XValidation:rule="!has(self.value) || !has(self.valueFrom)",message="value and valueFrom are mutually exclusive"
+type EnvVar struct {
+	// Name of the variable. Cronicle upper-cases parameter names, so it must be upper-case.
+	// +kubebuilder:validation:Pattern=`^[A-Z_][A-Z0-9_]*$`
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	// ValueFrom reads the value from a key of a Secret or ConfigMap in the namespace of the event
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource selects the key holding the value of an environment variable. Exactly one of
+// its fields must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="exactly one of configMapKeyRef and secretKeyRef must be set"
+type EnvVarSource struct {
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// TemplateValuesSource selects the ConfigMap whose keys are added to the template values
+type TemplateValuesSource struct {
+	ConfigMapRef corev1.ConfigMapEnvSource `json:"configMapRef"`
+}
+
+const (
+	DriftPolicyEnforce = "Enforce"
+	DriftPolicyReport  = "Report"
+	DriftPolicyIgnore  = "Ignore"
+)
+
+const (
+	DeletionPolicyDelete  = "Delete"
+	DeletionPolicyDisable = "Disable"
+	DeletionPolicyOrphan  = "Orphan"
+)
+
+const (
+	CronicleInstanceKind        = "CronicleInstance"
+	ClusterCronicleInstanceKind = "ClusterCronicleInstance"
+)
+
+// InstanceReference points to a CronicleInstance in the event's namespace or to a ClusterCronicleInstance
+type InstanceReference struct {
+	// +kubebuilder:default="CronicleInstance"
+	// +kubebuilder:validation:Enum=CronicleInstance;ClusterCronicleInstance
+	Kind string `json:"kind,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// RunNowAnnotation starts one job of the event whenever its value changes. Set it to a
+// timestamp or any other nonce, e.g. kubectl annotate cronicleevent foo cronicle.net/run-now="$(date +%s)"
+const RunNowAnnotation = "cronicle.net/run-now"
+
+// ForceDeleteAnnotation set to "true" lets a deleted CronicleEvent go away when its instance
+// is missing, unreachable or misconfigured, leaving the event in Cronicle behind
+const ForceDeleteAnnotation = "cronicle.net/force-delete"
+
+// AdoptEventAnnotation names an existing Cronicle event the CronicleEvent takes over instead of
+// creating a new one, e.g. one kept by the Disable or Orphan deletion policy. Events still owned
+// by another CronicleEvent are not adopted.
+const AdoptEventAnnotation = "cronicle.net/adopt-event-id"
+
+// Condition types of a CronicleEvent
+const (
+	ConditionReady            = "Ready"
+	ConditionSynced           = "Synced"
+	ConditionInstanceResolved = "InstanceResolved"
+	ConditionDeleting         = "Deleting"
+	ConditionDrifted          = "Drifted"
+	ConditionMissedSchedule   = "MissedSchedule"
+	ConditionRunNow           = "RunNow"
+)
+
+// Condition reasons of a CronicleEvent
+const (
+	ReasonResolved            = "Resolved"
+	ReasonInstanceNotSet      = "InstanceNotSet"
+	ReasonInstanceNotFound    = "InstanceNotFound"
+	ReasonNoMatchingService   = "NoMatchingService"
+	ReasonSecretNotFound      = "SecretNotFound"
+	ReasonNamespaceNotAllowed = "NamespaceNotAllowed"
+	ReasonInvalidInstance     = "InvalidInstance"
+	ReasonCreated             = "Created"
+	ReasonAdopted             = "Adopted"
+	ReasonCreateFailed        = "CreateFailed"
+	ReasonUpdated             = "Updated"
+	ReasonUpdateFailed        = "UpdateFailed"
+	ReasonInSync              = "InSync"
+	ReasonDisabled            = "Disabled"
+	ReasonDisableFailed       = "DisableFailed"
+	ReasonRunningJobs         = "RunningJobs"
+	ReasonDeleting            = "Deleting"
+	ReasonDeleted             = "Deleted"
+	ReasonDeleteFailed        = "DeleteFailed"
+	ReasonReleased            = "Released"
+	ReasonReleaseFailed       = "ReleaseFailed"
+	ReasonJobsAborted         = "JobsAborted"
+	ReasonAbortFailed         = "AbortFailed"
+	ReasonInstanceUnavailable = "InstanceUnavailable"
+	ReasonAbandoned           = "Abandoned"
+	ReasonNoDrift             = "NoDrift"
+	ReasonDriftDetected       = "DriftDetected"
+	ReasonDriftCorrected      = "DriftCorrected"
+	ReasonEventMissing        = "EventMissing"
+	ReasonEventRecreated      = "EventRecreated"
+	ReasonDriftCheckFailed    = "DriftCheckFailed"
+	ReasonUnauthorized        = "Unauthorized"
+	ReasonInvalidSchedule     = "InvalidSchedule"
+	ReasonOnSchedule          = "OnSchedule"
+	ReasonRunNotStarted       = "RunNotStarted"
+	ReasonRunNotSucceeded     = "RunNotSucceeded"
+	ReasonRunStarted          = "RunStarted"
+	ReasonRunPending          = "RunPending"
+	ReasonScriptNotFound      = "ScriptNotFound"
+	ReasonValuesNotFound      = "TemplateValuesNotFound"
+	ReasonRenderFailed        = "RenderFailed"
+	ReasonEnvNotFound         = "EnvNotFound"
+	ReasonInvalidEnv          = "InvalidEnv"
+	ReasonNameNotFound        = "NameNotFound"
+	ReasonResolveFailed       = "ResolveFailed"
+)
+
+// Results of a finished job
+const (
+	JobResultSucceeded = "Succeeded"
+	JobResultFailed    = "Failed"
+)
+
+// JobSummary is a finished job of the event as recorded in the Cronicle history
+type JobSummary struct {
+	JobId     string      `json:"jobId"`
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// Duration is how long the job ran
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	Result string `json:"result"`
+	// Code is 0 on success, otherwise the exit status of the script or a Cronicle error code
+	Code string `json:"code,omitempty"`
+	// Description is the error message of a failed job
+	Description string `json:"description,omitempty"`
+}
+
 // CronicleEventStatus defines the observed state of CronicleEvent
 type CronicleEventStatus struct {
 	EventId         string            `json:"eventId,omitempty"`
 	Modified        int64             `json:"modified,omitempty"`
 	EventStatus     string            `json:"eventStatus,omitempty"`
 	LastHandledSpec CronicleEventSpec `json:"lastHandledSpec,omitempty"`
+	// ParamsHash is the hash of the plugin parameters last sent to Cronicle. It detects
+	// changes made outside of the spec, such as to the script referenced by scriptFrom.
+	ParamsHash string `json:"paramsHash,omitempty"`
+
+	// RunNow is the last value of the cronicle.net/run-now annotation a job was started for.
+	RunNow string `json:"runNow,omitempty"`
+	// RunNowJobIds are the IDs of the jobs started for RunNow.
+	RunNowJobIds []string `json:"runNowJobIds,omitempty"`
+
+	// AbortedJobIds are the running jobs aborted to delete the event.
+	AbortedJobIds []string `json:"abortedJobIds,omitempty"`
+
+	// LastRun is when the most recent finished job started.
+	LastRun *metav1.Time `json:"lastRun,omitempty"`
+	// LastSuccessfulRun is when the most recent successful job started.
+	LastSuccessfulRun *metav1.Time `json:"lastSuccessfulRun,omitempty"`
+	LastJobId         string       `json:"lastJobId,omitempty"`
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	LastResult   string           `json:"lastResult,omitempty"`
+	LastDuration *metav1.Duration `json:"lastDuration,omitempty"`
+	// RecentJobs are the latest finished jobs, most recent first.
+	RecentJobs []JobSummary `json:"recentJobs,omitempty"`
+
+	// NextRunTime is when Cronicle will next start the event, as computed at the last sync.
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+	// ScheduleDescription describes the timing of the event in words.
+	ScheduleDescription string `json:"scheduleDescription,omitempty"`
+
+	// ScheduledSince is when the current timing was sent to Cronicle. Runs scheduled
+	// before it are not checked for.
+	ScheduledSince *metav1.Time `json:"scheduledSince,omitempty"`
+	// LastMissedRun is the scheduled time of the latest run that did not succeed in time.
+	LastMissedRun *metav1.Time `json:"lastMissedRun,omitempty"`
+
+	// ObservedGeneration is the generation last acted on by the reconciler.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Event ID",type=string,JSONPath=`.status.eventId`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.status.scheduleDescription`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Last Result",type=string,JSONPath=`.status.lastResult`
+// +kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.lastRun`
+// +kubebuilder:printcolumn:name="Next Run",type=date,JSONPath=`.status.nextRunTime`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // CronicleEvent is the Schema for the cronicleevents API
 type CronicleEvent struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
+	// +kubebuilder:validation:XValidation:rule="has(self.category) != has(self.categoryName)",message="exactly one of category and categoryName must be set"
+	// +kubebuilder:validation:XValidation:rule="(has(self.target) ? 1 : 0) + (has(self.targetGroupName) ? 1 : 0) + (has(self.targetHostname) ? 1 : 0) == 1",message="exactly one of target, targetGroupName and targetHostname must be set"
+	// +kubebuilder:validation:XValidation:rule="!has(self.plugin) || !has(self.pluginName)",message="plugin and pluginName are mutually exclusive"
 	Spec   CronicleEventSpec   `json:"spec,omitempty"`
 	Status CronicleEventStatus `json:"status,omitempty"`
 }
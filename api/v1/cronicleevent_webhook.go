@@ -0,0 +1,90 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/schedule"
+)
+
+// log is for logging in this package.
+var cronicleeventlog = logf.Log.WithName("cronicleevent-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *CronicleEvent) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-cronicle-net-v1-cronicleevent,mutating=false,failurePolicy=fail,sideEffects=None,groups=cronicle.net,resources=cronicleevents,verbs=create;update,versions=v1,name=vcronicleevent.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &CronicleEvent{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *CronicleEvent) ValidateCreate() (admission.Warnings, error) {
+	cronicleeventlog.Info("validate create", "name", r.Name)
+	return nil, r.validateCronicleEvent()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Updates that leave the spec alone, such as removing the finalizer of a deleted event, are
+// admitted so that events admitted earlier with other rules or time zone data are never stuck.
+func (r *CronicleEvent) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	cronicleeventlog.Info("validate update", "name", r.Name)
+	if r.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	if oldEvent, ok := old.(*CronicleEvent); ok && equality.Semantic.DeepEqual(r.Spec, oldEvent.Spec) {
+		return nil, nil
+	}
+	return nil, r.validateCronicleEvent()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *CronicleEvent) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateCronicleEvent checks what the OpenAPI schema of the CRD cannot express
+func (r *CronicleEvent) validateCronicleEvent() error {
+	var allErrs field.ErrorList
+	if r.Spec.Schedule != "" {
+		if _, err := schedule.Parse(r.Spec.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "schedule"), r.Spec.Schedule, err.Error()))
+		}
+	}
+	if _, err := time.LoadLocation(r.Spec.Timezone); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "timezone"), r.Spec.Timezone, err.Error()))
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("CronicleEvent").GroupKind(), r.Name, allErrs)
+}
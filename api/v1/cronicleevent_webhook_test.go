@@ -0,0 +1,101 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CronicleEvent Webhook", func() {
+
+	newEvent := func(name, schedule string) *CronicleEvent {
+		return &CronicleEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: CronicleEventSpec{
+				Category: "general",
+				Enabled:  1,
+				Plugin:   "shellplug",
+				Target:   "allgrp",
+				Title:    "Webhook test",
+				Schedule: schedule,
+			},
+		}
+	}
+
+	Context("When creating CronicleEvent under Validating Webhook", func() {
+		It("Should deny an invalid schedule", func() {
+			err := k8sClient.Create(ctx, newEvent("invalid-schedule", "61 * * * *"))
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.schedule"))
+		})
+
+		It("Should admit a valid schedule", func() {
+			event := newEvent("valid-schedule", "*/5 9-17 * * MON-FRI")
+			Expect(k8sClient.Create(ctx, event)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, event)).To(Succeed())
+		})
+
+		It("Should deny an unknown timezone", func() {
+			event := newEvent("invalid-timezone", "@daily")
+			event.Spec.Timezone = "Mars/Olympus_Mons"
+			err := k8sClient.Create(ctx, event)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.timezone"))
+		})
+
+		It("Should deny a schedule together with a timing", func() {
+			event := newEvent("schedule-and-timing", "@hourly")
+			event.Spec.Timing.Minutes = []int{0}
+			Expect(k8sClient.Create(ctx, event)).NotTo(Succeed())
+		})
+	})
+
+	Context("When updating CronicleEvent under Validating Webhook", func() {
+		It("Should deny a spec change to an unknown timezone", func() {
+			old := newEvent("update-timezone", "@daily")
+			event := old.DeepCopy()
+			event.Spec.Timezone = "Mars/Olympus_Mons"
+			_, err := event.ValidateUpdate(old)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		})
+
+		It("Should admit updates leaving an invalid spec unchanged", func() {
+			old := newEvent("unchanged-spec", "@daily")
+			old.Spec.Timezone = "Mars/Olympus_Mons"
+			event := old.DeepCopy()
+			event.Labels = map[string]string{"team": "payments"}
+			_, err := event.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should admit any update of an event being deleted", func() {
+			old := newEvent("deleted-event", "@daily")
+			old.Spec.Timezone = "Mars/Olympus_Mons"
+			event := old.DeepCopy()
+			event.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			event.Finalizers = nil
+			event.Spec.Schedule = "61 * * * *"
+			_, err := event.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
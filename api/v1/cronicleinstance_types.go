@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceReference points to the Service in front of a Cronicle master
+type ServiceReference struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Port is the name of the service port to use. The first port is used when empty.
+	Port string `json:"port,omitempty"`
+}
+
+// CronicleInstanceSpec defines how the operator reaches a Cronicle cluster
+type CronicleInstanceSpec struct {
+	// URL is the base URL of the Cronicle API, e.g. http://cronicle.example.com:3012.
+	// Either URL or ServiceRef must be set.
+	URL string `json:"url,omitempty"`
+
+	ServiceRef *ServiceReference `json:"serviceRef,omitempty"`
+
+	// Scheme is only used together with ServiceRef.
+	// +kubebuilder:default="http"
+	// +kubebuilder:validation:Enum=http;https
+	Scheme string `json:"scheme,omitempty"`
+
+	// APIKeySecretRef selects the key of a Secret holding the Cronicle API key.
+	// +kubebuilder:validation:Required
+	APIKeySecretRef corev1.SecretKeySelector `json:"apiKeySecretRef"`
+
+	// +kubebuilder:default="10s"
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=0
+	RetryAttempts int `json:"retryAttempts,omitempty"`
+}
+
+// CronicleInstanceStatus defines the observed state of CronicleInstance
+type CronicleInstanceStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.serviceRef.name`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CronicleInstance is the Schema for the cronicleinstances API
+type CronicleInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CronicleInstanceSpec   `json:"spec,omitempty"`
+	Status CronicleInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CronicleInstanceList contains a list of CronicleInstance
+type CronicleInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CronicleInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CronicleInstance{}, &CronicleInstanceList{})
+}
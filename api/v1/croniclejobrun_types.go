@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CronicleJobRunSpec defines a single run of a CronicleEvent. It cannot be changed once created.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type CronicleJobRunSpec struct {
+	// EventRef names the CronicleEvent in the same namespace to run.
+	// +kubebuilder:validation:Required
+	EventRef corev1.LocalObjectReference `json:"eventRef"`
+
+	// Script replaces the script of the event for this run only.
+	Script string `json:"script,omitempty"`
+
+	// Params override plugin parameters of the event for this run only.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Phases of a CronicleJobRun
+const (
+	JobRunPhasePending   = "Pending"
+	JobRunPhaseRunning   = "Running"
+	JobRunPhaseSucceeded = "Succeeded"
+	JobRunPhaseFailed    = "Failed"
+)
+
+// ConditionComplete is True once the job of a CronicleJobRun finished, whatever its result
+const ConditionComplete = "Complete"
+
+// Condition reasons of a CronicleJobRun
+const (
+	ReasonEventNotFound = "EventNotFound"
+	ReasonEventNotReady = "EventNotReady"
+	ReasonRunFailed     = "RunFailed"
+	ReasonJobRunning    = "JobRunning"
+	ReasonJobSucceeded  = "JobSucceeded"
+	ReasonJobFailed     = "JobFailed"
+	ReasonJobNotFound   = "JobNotFound"
+)
+
+// CronicleJobRunStatus defines the observed state of CronicleJobRun
+type CronicleJobRunStatus struct {
+	// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+	Phase string `json:"phase,omitempty"`
+
+	// EventId is the Cronicle ID of the event that was run.
+	EventId string `json:"eventId,omitempty"`
+	// JobId is the Cronicle ID of the tracked job. Multiplexed events start one job per
+	// server, only the first one is tracked.
+	JobId string `json:"jobId,omitempty"`
+	// JobIds are the IDs of all jobs started by the run.
+	JobIds []string `json:"jobIds,omitempty"`
+
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// ExitCode is 0 when the job succeeded, otherwise the exit status of the script or
+	// the error code reported by Cronicle.
+	ExitCode string `json:"exitCode,omitempty"`
+	// Description is the result description reported by Cronicle.
+	Description string `json:"description,omitempty"`
+	// LogExcerpt is the end of the job log.
+	LogExcerpt string `json:"logExcerpt,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Event",type=string,JSONPath=`.spec.eventRef.name`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Job ID",type=string,JSONPath=`.status.jobId`
+// +kubebuilder:printcolumn:name="Exit Code",type=string,JSONPath=`.status.exitCode`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CronicleJobRun is the Schema for the croniclejobruns API
+type CronicleJobRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CronicleJobRunSpec   `json:"spec,omitempty"`
+	Status CronicleJobRunStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CronicleJobRunList contains a list of CronicleJobRun
+type CronicleJobRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CronicleJobRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CronicleJobRun{}, &CronicleJobRunList{})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// EventParams are the plugin parameters of an event. Script, Annotate and Json are the
+// parameters of the built-in Shell Plugin. Parameters of any other plugin are set next to
+// them under their parameter ID and passed to Cronicle as is.
+// +kubebuilder:pruning:PreserveUnknownFields
+// +kubebuilder:validation:XValidation:rule="!has(self.script) || !has(self.scriptFrom)",message="script and scriptFrom are mutually exclusive"
+type EventParams struct {
+	Script string `json:"script,omitempty"`
+	// ScriptFrom reads the script from a key of a ConfigMap or Secret in the namespace of the
+	// event. The event is updated in Cronicle whenever the content of the key changes.
+	ScriptFrom *ScriptSource `json:"scriptFrom,omitempty"`
+	Annotate   int           `json:"annotate,omitempty"`
+	Json       int           `json:"json,omitempty"`
+
+	// Custom holds the parameters other than the Shell Plugin ones, keyed by parameter ID
+	Custom map[string]apiextensionsv1.JSON `json:"-"`
+}
+
+// ScriptSource selects the key holding a script. Exactly one of its fields must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="exactly one of configMapKeyRef and secretKeyRef must be set"
+type ScriptSource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+}
+
+// shellParams are the typed Shell Plugin parameters of EventParams
+type shellParams struct {
+	Script     string        `json:"script,omitempty"`
+	ScriptFrom *ScriptSource `json:"scriptFrom,omitempty"`
+	Annotate   int           `json:"annotate,omitempty"`
+	Json       int           `json:"json,omitempty"`
+}
+
+// UnmarshalJSON reads the Shell Plugin parameters into their fields and keeps the others in Custom
+func (p *EventParams) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var shell shellParams
+	if err := json.Unmarshal(data, &shell); err != nil {
+		return fmt.Errorf("invalid shell plugin params: %w", err)
+	}
+	*p = EventParams{Script: shell.Script, ScriptFrom: shell.ScriptFrom, Annotate: shell.Annotate, Json: shell.Json}
+	for key, value := range fields {
+		switch key {
+		case "script", "scriptFrom", "annotate", "json":
+			continue
+		}
+		if p.Custom == nil {
+			p.Custom = map[string]apiextensionsv1.JSON{}
+		}
+		p.Custom[key] = apiextensionsv1.JSON{Raw: value}
+	}
+	return nil
+}
+
+// MarshalJSON writes all parameters as a single object
+func (p EventParams) MarshalJSON() ([]byte, error) {
+	values := p.Values()
+	if p.ScriptFrom != nil {
+		values["scriptFrom"] = p.ScriptFrom
+	}
+	return json.Marshal(values)
+}
+
+// Values returns the parameters as sent to Cronicle. The script of ScriptFrom is not read.
+func (p *EventParams) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(p.Custom)+3)
+	for key, value := range p.Custom {
+		values[key] = json.RawMessage(value.Raw)
+	}
+	if p.Script != "" {
+		values["script"] = p.Script
+	}
+	if p.Annotate != 0 {
+		values["annotate"] = p.Annotate
+	}
+	if p.Json != 0 {
+		values["json"] = p.Json
+	}
+	return values
+}
@@ -19,7 +19,9 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -57,6 +59,10 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var resyncInterval time.Duration
+	var orphanSweepInterval time.Duration
+	var unreachableInstanceTimeout time.Duration
+	var orphanPolicy string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
 		"Use the port :8080. If not set, it will be 0 in order to disable the metrics server")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -67,6 +73,17 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&resyncInterval, "resync-interval", 5*time.Minute,
+		"How often each CronicleEvent is compared with the live event in Cronicle to detect drift.")
+	flag.DurationVar(&unreachableInstanceTimeout, "unreachable-instance-timeout", time.Hour,
+		"How long the Cronicle instance of a deleted CronicleEvent may stay missing or unreachable before the "+
+			"CronicleEvent is removed without cleaning up Cronicle. Set to 0 to wait for the cronicle.net/force-delete annotation.")
+	flag.DurationVar(&orphanSweepInterval, "orphan-sweep-interval", time.Hour,
+		"How often the schedule of every instance is searched for events whose CronicleEvent no longer exists. "+
+			"Set to 0 to disable the sweep.")
+	flag.StringVar(&orphanPolicy, "orphan-policy", controller.OrphanPolicyReport,
+		"What to do with orphaned events: Report, Disable or Delete. "+
+			"Do not use Disable or Delete when several clusters share a Cronicle.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -75,6 +92,13 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	switch orphanPolicy {
+	case controller.OrphanPolicyReport, controller.OrphanPolicyDisable, controller.OrphanPolicyDelete:
+	default:
+		setupLog.Error(fmt.Errorf("unknown orphan policy %q", orphanPolicy), "invalid flags")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -124,12 +148,39 @@ func main() {
 	}
 
 	if err = (&controller.CronicleEventReconciler{
+		Client:                     mgr.GetClient(),
+		Scheme:                     mgr.GetScheme(),
+		ResyncInterval:             resyncInterval,
+		UnreachableInstanceTimeout: unreachableInstanceTimeout,
+		Recorder:                   mgr.GetEventRecorderFor("cronicleevent-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CronicleEvent")
+		os.Exit(1)
+	}
+	if err = (&controller.CronicleJobRunReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "CronicleEvent")
+		setupLog.Error(err, "unable to create controller", "controller", "CronicleJobRun")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&croniclenetv1.CronicleEvent{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CronicleEvent")
+			os.Exit(1)
+		}
+	}
+	if orphanSweepInterval > 0 {
+		if err = mgr.Add(&controller.OrphanSweeper{
+			Client:   mgr.GetClient(),
+			Interval: orphanSweepInterval,
+			Policy:   orphanPolicy,
+			Recorder: mgr.GetEventRecorderFor("orphan-sweeper"),
+		}); err != nil {
+			setupLog.Error(err, "unable to add the orphan sweeper")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
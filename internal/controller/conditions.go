@@ -0,0 +1,54 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+)
+
+// setCondition records a condition for the current generation of the event and reports whether it changed
+func setCondition(cronicleEvent *croniclenetv1.CronicleEvent, conditionType string, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(&cronicleEvent.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cronicleEvent.Generation,
+	})
+}
+
+// setNotReady records a failing condition together with Ready=False carrying the same reason
+func setNotReady(cronicleEvent *croniclenetv1.CronicleEvent, conditionType, reason, message string) {
+	setCondition(cronicleEvent, conditionType, metav1.ConditionFalse, reason, message)
+	setCondition(cronicleEvent, croniclenetv1.ConditionReady, metav1.ConditionFalse, reason, message)
+}
+
+// setSynced marks the event as in sync with Cronicle for its current generation
+func setSynced(cronicleEvent *croniclenetv1.CronicleEvent, reason, message string) bool {
+	changed := setCondition(cronicleEvent, croniclenetv1.ConditionSynced, metav1.ConditionTrue, reason, message)
+	if setCondition(cronicleEvent, croniclenetv1.ConditionReady, metav1.ConditionTrue, croniclenetv1.ReasonInSync, "Event is in sync with Cronicle") {
+		changed = true
+	}
+	if cronicleEvent.Status.ObservedGeneration != cronicleEvent.Generation {
+		cronicleEvent.Status.ObservedGeneration = cronicleEvent.Generation
+		changed = true
+	}
+	return changed
+}
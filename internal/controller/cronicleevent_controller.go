@@ -19,74 +19,70 @@ package controller
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	"github.com/yasinahlattci/cronicle-operator/pkg/schedule"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"os"
+	"k8s.io/client-go/tools/record"
 	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	controllerutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"time"
 
 	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
 )
 
+// defaultResyncInterval is used when CronicleEventReconciler.ResyncInterval is not set
+const defaultResyncInterval = 5 * time.Minute
+
 // CronicleEventReconciler reconciles a CronicleEvent object
 type CronicleEventReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
-}
 
-// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents/finalizers,verbs=update
+	// ResyncInterval is how often an event is compared with its live copy in Cronicle
+	ResyncInterval time.Duration
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CronicleEvent object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.18.2/pkg/reconcile
-
-func (r *CronicleEventReconciler) getFirstMatchingService(ctx context.Context, namespace string, instanceSelector *metav1.LabelSelector) (*corev1.Service, error) {
-	// Convert to selector
-	selector, err := metav1.LabelSelectorAsSelector(instanceSelector)
-	if err != nil {
-		return nil, err
-	}
+	// ClientFactory builds the Cronicle client of an instance, cronicle_client.NewCronicleAPI when nil
+	ClientFactory cronicle_client.ClientFactory
 
-	serviceList := &corev1.ServiceList{}
-	listOptions := &client.ListOptions{
-		Namespace:     namespace,
-		LabelSelector: selector,
-	}
-	err = r.List(ctx, serviceList, listOptions)
-	if err != nil {
-		return nil, err
-	}
+	// UnreachableInstanceTimeout is how long a deleted CronicleEvent waits for a missing or
+	// unreachable instance before its finalizer is removed anyway, counted from when the
+	// instance became unavailable. Zero waits until the cronicle.net/force-delete annotation is set.
+	UnreachableInstanceTimeout time.Duration
 
-	if len(serviceList.Items) == 0 {
-		return nil, errors.New("no matching services found")
-	}
+	Recorder record.EventRecorder
 
-	// Return the first matching service
-	return &serviceList.Items[0], nil
+	// names caches the lists category, target and plugin names are resolved against
+	names nameCache
 }
 
+// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents/finalizers,verbs=update
+// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cronicle.net,resources=clustercronicleinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile keeps the Cronicle event of a CronicleEvent in line with its spec. It adds the
+// finalizer first, applies the deletion policy once the object is deleted, then creates or
+// adopts the event, pushes spec and parameter changes to it and otherwise checks it for drift,
+// missed runs and run-now requests on every resync.
 func (r *CronicleEventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	l := log.FromContext(ctx)
 
-	// Get the service URL
-
 	cronicleEvent := &croniclenetv1.CronicleEvent{}
 
 	err := r.Get(ctx, types.NamespacedName{Name: req.Name, Namespace: req.Namespace}, cronicleEvent)
@@ -107,44 +103,94 @@ func (r *CronicleEventReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, nil
 	}
 
-	labelSelector := cronicleEvent.Spec.InstanceSelector
+	if cronicleEvent.GetDeletionTimestamp() != nil && cronicleEvent.Status.EventId == "" {
+		// Nothing was created in Cronicle
+		return r.removeFinalizer(ctx, cronicleEvent)
+	}
 
-	service, err := r.getFirstMatchingService(ctx, req.Namespace, labelSelector)
+	clientConfig, err := instanceResolver{r.Client}.resolveInstance(ctx, cronicleEvent)
+	if err != nil && cronicleEvent.GetDeletionTimestamp() != nil {
+		return r.instanceResolveFailed(ctx, cronicleEvent, err)
+	}
 	if err != nil {
+		reason := instanceFailureReason(err)
+		setNotReady(cronicleEvent, croniclenetv1.ConditionInstanceResolved, reason, err.Error())
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, reason, err.Error())
+		if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+			l.Error(statusErr, "Failed to update status")
+		}
+		if reason == croniclenetv1.ReasonNamespaceNotAllowed {
+			// Reconciled again by the watches when the instance or the namespace labels change
+			l.Info("Instance refused the event", "reason", err.Error())
+			return ctrl.Result{}, nil
+		}
 		l.Error(err, "No instance found for the event")
 		return ctrl.Result{}, err
 	}
-	serviceUrl := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port)
+	resolvedChanged := setCondition(cronicleEvent, croniclenetv1.ConditionInstanceResolved, metav1.ConditionTrue, croniclenetv1.ReasonResolved, "Instance resolved to "+clientConfig.BaseUrl)
 
-	cronicleClient := cronicle_client.NewClient(cronicle_client.Config{
-		BaseUrl:       serviceUrl,
-		APIKey:        os.Getenv("CRONICLE_API_KEY"),
-		Timeout:       10 * time.Second,
-		RetryAttempts: 2,
-	})
+	cronicleClient, err := newCronicleClient(r.ClientFactory, clientConfig, instanceLabel(cronicleEvent))
+	if err != nil && cronicleEvent.GetDeletionTimestamp() != nil {
+		if cronicle_client.IsTransient(err) {
+			return r.instanceUnavailable(ctx, cronicleEvent, err)
+		}
+		return r.deletionBlocked(ctx, cronicleEvent, croniclenetv1.ReasonInvalidInstance, err)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
 	// Check if the event is being deleted
 	if cronicleEvent.GetDeletionTimestamp() != nil {
+		if policy := cronicleEvent.Spec.DeletionPolicy; policy == croniclenetv1.DeletionPolicyDisable || policy == croniclenetv1.DeletionPolicyOrphan {
+			return r.releaseEvent(ctx, cronicleClient, cronicleEvent)
+		}
 		if cronicleEvent.Status.EventStatus == "markedForDeletion" {
-			resp, err := cronicleClient.CheckRunningJobs(cronicleEvent.Status.EventId)
+			resp, err := cronicleClient.CheckRunningJobs(ctx, cronicleEvent.Status.EventId)
 			if err != nil {
 				l.Error(err, "Failed to check running jobs")
-				return ctrl.Result{}, err
+				return r.deletionFailed(ctx, cronicleEvent, err)
+			}
+			if instanceAvailable(cronicleEvent) {
+				if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+					return ctrl.Result{}, err
+				}
 			}
 			if resp {
+				gracePeriodLeft := deletionGracePeriodLeft(cronicleEvent)
+				if cronicleEvent.Spec.AbortRunningJobsOnDelete && gracePeriodLeft <= 0 {
+					return r.abortRunningJobs(ctx, cronicleClient, cronicleEvent)
+				}
 				l.Info("Event has running jobs, queueing for deletion", "eventId", cronicleEvent.Status.EventId)
-				return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+				if setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, croniclenetv1.ReasonRunningJobs, "Deletion is blocked by running jobs") {
+					r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonRunningJobs, "Deletion of event %s is blocked by running jobs", cronicleEvent.Status.EventId)
+					if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				requeueAfter := 60 * time.Second
+				if cronicleEvent.Spec.AbortRunningJobsOnDelete && gracePeriodLeft < requeueAfter {
+					requeueAfter = gracePeriodLeft
+				}
+				return ctrl.Result{RequeueAfter: requeueAfter}, nil
 			}
 
-			err = cronicleClient.DeleteEvent(cronicleEvent.Status.EventId)
-
-			if err != nil {
+			err = cronicleClient.DeleteEvent(ctx, cronicleEvent.Status.EventId)
+			if cronicle_client.IsTransient(err) {
+				l.Error(err, "Failed to delete event, retrying", "eventId", cronicleEvent.Status.EventId)
+				return r.deletionFailed(ctx, cronicleEvent, err)
+			}
+			if err != nil && !cronicle_client.IsNotFound(err) {
 				l.Info("Failed to delete event", "eventId", cronicleEvent.Status.EventId)
 				l.Info("Error", "err", err)
+				r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonDeleteFailed, "Failed to delete event %s, leaving it disabled in Cronicle: %v", cronicleEvent.Status.EventId, err)
+			} else {
+				r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonDeleted, "Event %s deleted from Cronicle", cronicleEvent.Status.EventId)
 			}
 			l.Info("Event deleted", "eventId", cronicleEvent.Status.EventId)
 			cronicleEvent.Status.EventStatus = "readyForDeletion"
 			controllerutil.RemoveFinalizer(cronicleEvent, "cronicle.net/eventfinalizer")
+			forgetEventMetrics(cronicleEvent)
 			err = r.Update(ctx, cronicleEvent)
 			if err != nil {
 				l.Error(err, "Failed to remove finalizer")
@@ -153,113 +199,318 @@ func (r *CronicleEventReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 		}
 		if cronicleEvent.Status.EventStatus == "created" {
-			err := cronicleClient.DisableEvent(cronicleEvent.Status.EventId)
-			if err != nil {
+			err := cronicleClient.DisableEvent(ctx, cronicleEvent.Status.EventId)
+			if err != nil && !cronicle_client.IsNotFound(err) {
 				l.Error(err, "Failed to disable event")
-				return ctrl.Result{}, err
+				reason := apiFailureReason(err, croniclenetv1.ReasonDisableFailed)
+				setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, reason, err.Error())
+				r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to disable event %s: %v", cronicleEvent.Status.EventId, err)
+				if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+					l.Error(statusErr, "Failed to update status")
+				}
+				return r.deletionFailed(ctx, cronicleEvent, err)
 			}
 			l.Info("Event disabled", "resp", cronicleEvent.Status.EventId)
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonDisabled, "Event %s disabled in Cronicle before deletion", cronicleEvent.Status.EventId)
 			cronicleEvent.Status.EventStatus = "markedForDeletion"
+			setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, croniclenetv1.ReasonDisabled, "Event disabled, waiting for running jobs before deleting it")
+			setCondition(cronicleEvent, croniclenetv1.ConditionReady, metav1.ConditionFalse, croniclenetv1.ReasonDeleting, "Event is being deleted")
 			err = r.Status().Update(ctx, cronicleEvent)
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
 	}
 
+	if cronicleEvent.Spec.Schedule != "" {
+		// Normally refused by the admission webhook, which may be disabled
+		if _, err := schedule.Parse(cronicleEvent.Spec.Schedule); err != nil {
+			l.Info("Invalid schedule", "schedule", cronicleEvent.Spec.Schedule, "err", err.Error())
+			setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, croniclenetv1.ReasonInvalidSchedule, err.Error())
+			return ctrl.Result{}, r.Status().Update(ctx, cronicleEvent)
+		}
+	}
+
+	params, hash, err := r.eventParams(ctx, cronicleEvent, clientConfig.BaseUrl)
+	var pe *paramsError
+	if errors.As(err, &pe) {
+		// Reconciled again when the referenced object changes
+		l.Info("Cannot build the plugin parameters", "reason", err.Error())
+		setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, pe.reason, err.Error())
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, pe.reason, err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, cronicleEvent)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	spec, err := resolveNames(ctx, cronicleClient, &r.names, clientConfig.BaseUrl, &cronicleEvent.Spec)
+	var ne *nameError
+	if errors.As(err, &ne) {
+		// Retried after the resync interval, the object may be created in Cronicle meanwhile
+		l.Info("Cannot resolve names", "reason", err.Error())
+		setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, croniclenetv1.ReasonNameNotFound, err.Error())
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonNameNotFound, err.Error())
+		return ctrl.Result{RequeueAfter: r.resyncInterval()}, r.Status().Update(ctx, cronicleEvent)
+	}
+	if err != nil {
+		l.Error(err, "Failed to resolve names")
+		reason := apiFailureReason(err, croniclenetv1.ReasonResolveFailed)
+		setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, reason, err.Error())
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to resolve names in Cronicle: %v", err)
+		if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+			l.Error(statusErr, "Failed to update status")
+		}
+		return r.apiErrorResult(err)
+	}
+
 	eventStatus := cronicleEvent.Status.EventStatus
 	eventId := cronicleEvent.Status.EventId
 	modifiedDate := time.Now().Unix()
 	cronicleEvent.Status.Modified = modifiedDate
 
 	if eventStatus == "" && eventId == "" {
-		createEventData := cronicle_client.CreateEventRequest{
-			CatchUp:       cronicleEvent.Spec.CatchUp,
-			Category:      cronicleEvent.Spec.Category,
-			CpuLimit:      cronicleEvent.Spec.CpuLimit,
-			CpuSustain:    cronicleEvent.Spec.CpuSustain,
-			Detached:      cronicleEvent.Spec.Detached,
-			Enabled:       cronicleEvent.Spec.Enabled,
-			LogMaxSize:    cronicleEvent.Spec.LogMaxSize,
-			MaxChildren:   cronicleEvent.Spec.MaxChildren,
-			MemoryLimit:   cronicleEvent.Spec.MemoryLimit,
-			MemorySustain: cronicleEvent.Spec.MemorySustain,
-			Multiplex:     cronicleEvent.Spec.Multiplex,
-			Notes:         cronicleEvent.Spec.Notes,
-			NotifyFail:    cronicleEvent.Spec.NotifyFail,
-			NotifySuccess: cronicleEvent.Spec.NotifySuccess,
-			Plugin:        cronicleEvent.Spec.Plugin,
-			Retries:       cronicleEvent.Spec.Retries,
-			RetryDelay:    cronicleEvent.Spec.RetryDelay,
-			Target:        cronicleEvent.Spec.Target,
-			Timeout:       cronicleEvent.Spec.Timeout,
-			Timezone:      cronicleEvent.Spec.Timezone,
-			Title:         cronicleEvent.Spec.Title,
-			WebHook:       cronicleEvent.Spec.WebHook,
-			Timing:        cronicleEvent.Spec.Timing,
-			Params:        cronicleEvent.Spec.Params,
-			Algorithm:     cronicleEvent.Spec.Algorithm,
+		eventID, adopted, err := r.createOrAdoptEvent(ctx, cronicleClient, cronicleEvent, spec, params)
+		if err != nil {
+			l.Error(err, "Failed to create event")
+			reason := apiFailureReason(err, croniclenetv1.ReasonCreateFailed)
+			setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, reason, err.Error())
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to create event in Cronicle: %v", err)
+			if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+				l.Error(statusErr, "Failed to update status")
+			}
+			return r.apiErrorResult(err)
 		}
-		eventID, err := cronicleClient.CreateEvent(createEventData)
 		cronicleEvent.Status.EventId = eventID
 		cronicleEvent.Status.EventStatus = "created"
-		if err != nil {
-			l.Error(err, "Failed to create event")
+		cronicleEvent.Status.ScheduledSince = &metav1.Time{Time: time.Unix(modifiedDate, 0)}
+		cronicleEvent.Status.LastHandledSpec = cronicleEvent.Spec
+		cronicleEvent.Status.ParamsHash = hash
+		if adopted {
+			setSynced(cronicleEvent, croniclenetv1.ReasonAdopted, "Event adopted from Cronicle")
+		} else {
+			l.Info("Event created", "resp", eventID)
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonCreated, "Event %s created in Cronicle", eventID)
+			setSynced(cronicleEvent, croniclenetv1.ReasonCreated, "Event created in Cronicle")
+		}
+		// Should this write fail, the event is adopted by the next reconcile
+		if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+			l.Error(err, "Failed to record the created event", "eventId", eventID)
 			return ctrl.Result{}, err
 		}
-		l.Info("Event created", "resp", eventID)
-		cronicleEvent.Status.LastHandledSpec = cronicleEvent.Spec
-		r.Status().Update(ctx, cronicleEvent)
 		return ctrl.Result{}, nil
 	}
 
-	if !reflect.DeepEqual(cronicleEvent.Spec, cronicleEvent.Status.LastHandledSpec) {
-		updateEventData := cronicle_client.UpdateEventRequest{
-			Id:            cronicleEvent.Status.EventId,
-			CatchUp:       cronicleEvent.Spec.CatchUp,
-			Category:      cronicleEvent.Spec.Category,
-			CpuLimit:      cronicleEvent.Spec.CpuLimit,
-			CpuSustain:    cronicleEvent.Spec.CpuSustain,
-			Detached:      cronicleEvent.Spec.Detached,
-			Enabled:       cronicleEvent.Spec.Enabled,
-			LogMaxSize:    cronicleEvent.Spec.LogMaxSize,
-			MaxChildren:   cronicleEvent.Spec.MaxChildren,
-			MemoryLimit:   cronicleEvent.Spec.MemoryLimit,
-			MemorySustain: cronicleEvent.Spec.MemorySustain,
-			Multiplex:     cronicleEvent.Spec.Multiplex,
-			Notes:         cronicleEvent.Spec.Notes,
-			NotifyFail:    cronicleEvent.Spec.NotifyFail,
-			NotifySuccess: cronicleEvent.Spec.NotifySuccess,
-			Plugin:        cronicleEvent.Spec.Plugin,
-			Retries:       cronicleEvent.Spec.Retries,
-			RetryDelay:    cronicleEvent.Spec.RetryDelay,
-			Target:        cronicleEvent.Spec.Target,
-			Timeout:       cronicleEvent.Spec.Timeout,
-			Timezone:      cronicleEvent.Spec.Timezone,
-			Title:         cronicleEvent.Spec.Title,
-			WebHook:       cronicleEvent.Spec.WebHook,
-			Timing:        cronicleEvent.Spec.Timing,
-			Params:        cronicleEvent.Spec.Params,
-			Algorithm:     cronicleEvent.Spec.Algorithm,
-		}
+	if !reflect.DeepEqual(cronicleEvent.Spec, cronicleEvent.Status.LastHandledSpec) || hash != cronicleEvent.Status.ParamsHash {
 		// It means event is already created, only update can be done, since delete is handled above
-		err := cronicleClient.UpdateEvent(updateEventData)
+		err := cronicleClient.UpdateEvent(ctx, updateEventRequest(cronicleEvent.Status.EventId, spec, params))
+		if cronicle_client.IsNotFound(err) && cronicleEvent.Spec.DriftPolicy == croniclenetv1.DriftPolicyEnforce {
+			// The event was deleted in Cronicle, forget it so that it is created again
+			l.Info("Event is missing in Cronicle, recreating it", "eventId", cronicleEvent.Status.EventId)
+			cronicleEvent.Status.EventId = ""
+			cronicleEvent.Status.EventStatus = ""
+			return ctrl.Result{Requeue: true}, r.Status().Update(ctx, cronicleEvent)
+		}
 		if err != nil {
 			l.Error(err, "Failed to update event")
-			return ctrl.Result{}, err
+			reason := apiFailureReason(err, croniclenetv1.ReasonUpdateFailed)
+			setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, reason, err.Error())
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to update event %s in Cronicle: %v", cronicleEvent.Status.EventId, err)
+			if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+				l.Error(statusErr, "Failed to update status")
+			}
+			return r.apiErrorResult(err)
 		}
 		l.Info("Event updated", "resp", cronicleEvent.Status.EventId)
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonUpdated, "Event %s updated in Cronicle", cronicleEvent.Status.EventId)
+		cronicleEvent.Status.ScheduledSince = &metav1.Time{Time: time.Unix(modifiedDate, 0)}
 		cronicleEvent.Status.LastHandledSpec = cronicleEvent.Spec
-		r.Status().Update(ctx, cronicleEvent)
+		cronicleEvent.Status.ParamsHash = hash
+		setSynced(cronicleEvent, croniclenetv1.ReasonUpdated, "Event updated in Cronicle")
+		// Should this write fail, the update is sent again by the next reconcile
+		if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+			l.Error(err, "Failed to record the updated event", "eventId", cronicleEvent.Status.EventId)
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{}, nil
+	statusChanged, err := r.reconcileDrift(ctx, cronicleClient, cronicleEvent, spec, params)
+	if err == nil {
+		var runChanged bool
+		runChanged, err = r.reconcileRunNow(ctx, cronicleClient, cronicleEvent)
+		statusChanged = statusChanged || runChanged
+	}
+	if err == nil {
+		// The job history is informational, failing to read it must not hold up the event
+		historyChanged, historyErr := r.reconcileHistory(ctx, cronicleClient, cronicleEvent)
+		if historyErr != nil {
+			l.Error(historyErr, "Failed to get event history", "eventId", cronicleEvent.Status.EventId)
+		} else if r.reconcileMissedSchedule(ctx, cronicleEvent) {
+			historyChanged = true
+		}
+		statusChanged = statusChanged || historyChanged
+	}
+	if r.reconcileNextRun(ctx, cronicleEvent) {
+		statusChanged = true
+	}
+	if statusChanged || resolvedChanged {
+		if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+	}
+	if err != nil {
+		return r.apiErrorResult(err)
+	}
+
+	return ctrl.Result{RequeueAfter: r.resyncInterval()}, nil
+
+}
+
+func (r *CronicleEventReconciler) resyncInterval() time.Duration {
+	if r.ResyncInterval > 0 {
+		return r.ResyncInterval
+	}
+	return defaultResyncInterval
+}
 
+// newCronicleClient builds a client with factory, cronicle_client.NewCronicleAPI when nil.
+// The calls made through it are recorded in the metrics of instance.
+func newCronicleClient(factory cronicle_client.ClientFactory, config cronicle_client.Config, instance string) (cronicle_client.CronicleAPI, error) {
+	if factory == nil {
+		factory = cronicle_client.NewCronicleAPI
+	}
+	api, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+	return instrumentedAPI{api: api, instance: instance}, nil
+}
+
+// apiErrorResult decides how a failed Cronicle call is retried. Transient failures are
+// returned so that the request is requeued with backoff, permanent ones such as a rejected
+// API key or an invalid event wait for the next resync or a change of the spec.
+func (r *CronicleEventReconciler) apiErrorResult(err error) (ctrl.Result, error) {
+	if cronicle_client.IsTransient(err) {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.resyncInterval()}, nil
+}
+
+// apiFailureReason returns reason unless Cronicle rejected the API key
+func apiFailureReason(err error, reason string) string {
+	if cronicle_client.IsUnauthorized(err) {
+		return croniclenetv1.ReasonUnauthorized
+	}
+	return reason
+}
+
+// eventTiming returns the Cronicle timing of a spec, converting its schedule when set.
+// Invalid schedules are rejected by Reconcile before any payload is built.
+func eventTiming(spec *croniclenetv1.CronicleEventSpec) cronicle_client.CronicleTiming {
+	if spec.Schedule == "" {
+		return spec.Timing
+	}
+	timing, err := schedule.Parse(spec.Schedule)
+	if err != nil {
+		return spec.Timing
+	}
+	return timing
+}
+
+// createEventRequest builds the Cronicle create_event payload of a spec resolved by resolveNames, with the plugin parameters returned by eventParams
+func createEventRequest(spec *croniclenetv1.CronicleEventSpec, params map[string]interface{}) cronicle_client.CreateEventRequest {
+	return cronicle_client.CreateEventRequest{
+		CatchUp:       spec.CatchUp,
+		Category:      spec.Category,
+		CpuLimit:      spec.CpuLimit,
+		CpuSustain:    spec.CpuSustain,
+		Detached:      spec.Detached,
+		Enabled:       spec.Enabled,
+		LogMaxSize:    spec.LogMaxSize,
+		MaxChildren:   spec.MaxChildren,
+		MemoryLimit:   spec.MemoryLimit,
+		MemorySustain: spec.MemorySustain,
+		Multiplex:     spec.Multiplex,
+		Notes:         spec.Notes,
+		NotifyFail:    spec.NotifyFail,
+		NotifySuccess: spec.NotifySuccess,
+		Plugin:        spec.Plugin,
+		Retries:       spec.Retries,
+		RetryDelay:    spec.RetryDelay,
+		Target:        spec.Target,
+		Timeout:       spec.Timeout,
+		Timezone:      spec.Timezone,
+		Title:         spec.Title,
+		WebHook:       spec.WebHook,
+		Timing:        eventTiming(spec),
+		Params:        params,
+		Algorithm:     spec.Algorithm,
+	}
+}
+
+// updateEventRequest builds the Cronicle update_event payload of a spec resolved by resolveNames, with the plugin parameters returned by eventParams
+func updateEventRequest(eventId string, spec *croniclenetv1.CronicleEventSpec, params map[string]interface{}) cronicle_client.UpdateEventRequest {
+	return cronicle_client.UpdateEventRequest{
+		Id:            eventId,
+		CatchUp:       spec.CatchUp,
+		Category:      spec.Category,
+		CpuLimit:      spec.CpuLimit,
+		CpuSustain:    spec.CpuSustain,
+		Detached:      spec.Detached,
+		Enabled:       spec.Enabled,
+		LogMaxSize:    spec.LogMaxSize,
+		MaxChildren:   spec.MaxChildren,
+		MemoryLimit:   spec.MemoryLimit,
+		MemorySustain: spec.MemorySustain,
+		Multiplex:     spec.Multiplex,
+		Notes:         spec.Notes,
+		NotifyFail:    spec.NotifyFail,
+		NotifySuccess: spec.NotifySuccess,
+		Plugin:        spec.Plugin,
+		Retries:       spec.Retries,
+		RetryDelay:    spec.RetryDelay,
+		Target:        spec.Target,
+		Timeout:       spec.Timeout,
+		Timezone:      spec.Timezone,
+		Title:         spec.Title,
+		WebHook:       spec.WebHook,
+		Timing:        eventTiming(spec),
+		Params:        params,
+		Algorithm:     spec.Algorithm,
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CronicleEventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &croniclenetv1.CronicleEvent{}, instanceRefIndex, func(obj client.Object) []string {
+		cronicleEvent := obj.(*croniclenetv1.CronicleEvent)
+		if cronicleEvent.Spec.InstanceRef == nil {
+			return nil
+		}
+		kind := cronicleEvent.Spec.InstanceRef.Kind
+		if kind == "" {
+			kind = croniclenetv1.CronicleInstanceKind
+		}
+		return []string{instanceRefKey(kind, cronicleEvent.Spec.InstanceRef.Name)}
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &croniclenetv1.CronicleEvent{}, paramsRefIndex, func(obj client.Object) []string {
+		return paramsRefs(obj.(*croniclenetv1.CronicleEvent))
+	})
+	if err != nil {
+		return err
+	}
+	eventsCollector.setReader(mgr.GetClient())
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&croniclenetv1.CronicleEvent{}).
+		Watches(&croniclenetv1.CronicleInstance{}, handler.EnqueueRequestsFromMapFunc(r.eventsForInstance)).
+		Watches(&croniclenetv1.ClusterCronicleInstance{}, handler.EnqueueRequestsFromMapFunc(r.eventsForClusterInstance)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.eventsForConfigMap)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.eventsForSecret)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.eventsForNamespace),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Complete(r)
 }
@@ -18,67 +18,1049 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client/fake"
 )
 
 var _ = Describe("CronicleEvent Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
+		const instanceName = "test-instance"
+		const secretName = "test-api-key"
 
 		ctx := context.Background()
 
 		typeNamespacedName := types.NamespacedName{
 			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
+			Namespace: "default",
+		}
+
+		var cronicle *fake.Server
+		var controllerReconciler *CronicleEventReconciler
+
+		reconcileEvent := func() (reconcile.Result, error) {
+			return controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+		}
+
+		getEvent := func() *croniclenetv1.CronicleEvent {
+			cronicleevent := &croniclenetv1.CronicleEvent{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, cronicleevent)).To(Succeed())
+			return cronicleevent
+		}
+
+		// reconcileCreated runs the reconciles adding the finalizer and creating the event, and returns its ID
+		reconcileCreated := func() string {
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			eventId := getEvent().Status.EventId
+			Expect(eventId).NotTo(BeEmpty())
+			return eventId
 		}
-		cronicleevent := &croniclenetv1.CronicleEvent{}
 
 		BeforeEach(func() {
+			cronicle = fake.NewServer()
+			controllerReconciler = &CronicleEventReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("creating the API key secret and the CronicleInstance")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+				StringData: map[string]string{"apiKey": fake.APIKey},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			instance := &croniclenetv1.CronicleInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: croniclenetv1.CronicleInstanceSpec{
+					URL: cronicle.URL,
+					APIKeySecretRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Key:                  "apiKey",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+
 			By("creating the custom resource for the Kind CronicleEvent")
-			err := k8sClient.Get(ctx, typeNamespacedName, cronicleevent)
-			if err != nil && errors.IsNotFound(err) {
-				resource := &croniclenetv1.CronicleEvent{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+			resource := &croniclenetv1.CronicleEvent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: croniclenetv1.CronicleEventSpec{
+					Category: "general",
+					Enabled:  1,
+					Plugin:   "shellplug",
+					Target:   "allgrp",
+					Timezone: "Europe/Istanbul",
+					Title:    "Test event",
+					InstanceRef: &croniclenetv1.InstanceReference{
+						Name: instanceName,
 					},
-					// TODO(user): Specify other spec details if needed.
-				}
-				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+				},
 			}
+			resource.Spec.Timing.Minutes = []int{0, 30}
+			resource.Spec.Params.Script = "#!/bin/bash\necho hello"
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 		})
 
 		AfterEach(func() {
-			// TODO(user): Cleanup logic after each test, like removing the resource instance.
+			cronicle.Close()
+
+			By("Cleanup the specific resource instance CronicleEvent")
 			resource := &croniclenetv1.CronicleEvent{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			if err == nil {
+				resource.Finalizers = nil
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, resource))).To(Succeed())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+
+			instance := &croniclenetv1.CronicleInstance{ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"}}
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"}}
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		})
+
+		It("should create, update and delete the event in Cronicle", func() {
+			By("Creating the event")
+			eventId := reconcileCreated()
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Test event"))
+			cronicleevent := getEvent()
+			Expect(meta.IsStatusConditionTrue(cronicleevent.Status.Conditions, croniclenetv1.ConditionReady)).To(BeTrue())
+			Expect(cronicleevent.Status.ObservedGeneration).To(Equal(cronicleevent.Generation))
+
+			By("Updating the event")
+			cronicleevent.Spec.Title = "Renamed event"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
 			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Renamed event"))
 
-			By("Cleanup the specific resource instance CronicleEvent")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			By("Disabling the event once the resource is deleted")
+			Expect(k8sClient.Delete(ctx, cronicleevent)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("enabled", BeEquivalentTo(0)))
+
+			By("Waiting for running jobs")
+			jobId := cronicle.StartJob(eventId)
+			result, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(60 * time.Second))
+			deleting := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting).NotTo(BeNil())
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonRunningJobs))
+
+			By("Deleting the event after the jobs finished")
+			cronicle.FinishJob(jobId)
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(BeNil())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 		})
-		It("should successfully reconcile the resource", func() {
-			By("Reconciling the created resource")
-			controllerReconciler := &CronicleEventReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+
+		It("should abort jobs still running after the deletion grace period", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.AbortRunningJobsOnDelete = true
+			cronicleevent.Spec.DeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+			jobId := cronicle.StartJob(eventId)
+
+			By("waiting for the jobs during the grace period")
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
 			}
+			Expect(cronicle.ActiveJobs(eventId)).To(HaveLen(1))
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
-			})
+			By("aborting them once it is over")
+			cronicleevent = getEvent()
+			cronicleevent.Spec.DeletionGracePeriod = &metav1.Duration{}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.ActiveJobs(eventId)).To(BeEmpty())
+			Expect(getEvent().Status.AbortedJobIds).To(ConsistOf(jobId))
+
+			// abortedEvents drains the recorded events and counts the JobsAborted ones
+			abortedEvents := func() int {
+				count := 0
+				events := controllerReconciler.Recorder.(*record.FakeRecorder).Events
+				for len(events) > 0 {
+					if strings.HasPrefix(<-events, "Warning "+croniclenetv1.ReasonJobsAborted) {
+						count++
+					}
+				}
+				return count
+			}
+			Expect(abortedEvents()).To(Equal(1))
+
+			By("not aborting again a job that is still winding down")
+			lingering := cronicle.StartJob(eventId)
+			cronicleevent = getEvent()
+			cronicleevent.Status.AbortedJobIds = append(cronicleevent.Status.AbortedJobIds, lingering)
+			Expect(k8sClient.Status().Update(ctx, cronicleevent)).To(Succeed())
+			abortCalls := cronicle.Calls(cronicle_client.AbortJobEndpoint)
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Calls(cronicle_client.AbortJobEndpoint)).To(Equal(abortCalls))
+			Expect(getEvent().Status.AbortedJobIds).To(ConsistOf(jobId, lingering))
+			Expect(abortedEvents()).To(BeZero())
+
+			By("aborting only the jobs started since")
+			cronicle.FinishJob(lingering)
+			started := cronicle.StartJob(eventId)
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getEvent().Status.AbortedJobIds).To(ConsistOf(jobId, lingering, started))
+			Expect(abortedEvents()).To(Equal(1))
+
+			By("deleting the event afterwards")
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(BeNil())
+		})
+
+		It("should handle an outage while aborting jobs like an unavailable instance", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.AbortRunningJobsOnDelete = true
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+			cronicle.StartJob(eventId)
+			controllerReconciler.ClientFactory = func(config cronicle_client.Config) (cronicle_client.CronicleAPI, error) {
+				api, err := cronicle_client.NewCronicleAPI(config)
+				return abortFailingAPI{api}, err
+			}
+
+			By("waiting when Cronicle does not answer the abort")
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			deleting := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonInstanceUnavailable))
+			Expect(cronicle.ActiveJobs(eventId)).To(HaveLen(1))
+
+			By("removing the finalizer once deletion is forced")
+			cronicleevent = getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.ForceDeleteAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		DescribeTable("should keep the event in Cronicle with a deletion policy other than Delete",
+			func(policy string, enabled int) {
+				cronicleevent := getEvent()
+				cronicleevent.Spec.DeletionPolicy = policy
+				Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+				eventId := reconcileCreated()
+				cronicle.StartJob(eventId)
+
+				Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+				err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+
+				Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("enabled", BeEquivalentTo(enabled)))
+				Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("k8s_owner_uid", BeEmpty()))
+			},
+			Entry("Disable", croniclenetv1.DeletionPolicyDisable, 0),
+			Entry("Orphan", croniclenetv1.DeletionPolicyOrphan, 1),
+		)
+
+		It("should keep the finalizer with the Orphan policy until the event is released", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.DeletionPolicy = croniclenetv1.DeletionPolicyOrphan
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+			cronicleevent = getEvent()
+			cronicleevent.Spec.InstanceRef.Name = "missing"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			sweep := func() {
+				sweeper := &OrphanSweeper{Client: k8sClient, Policy: OrphanPolicyDelete, Recorder: record.NewFakeRecorder(100)}
+				Expect(sweeper.Sweep(ctx)).To(Succeed())
+			}
+
+			By("waiting for the instance while the release fails")
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			deleting := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonInstanceUnavailable))
+			sweep()
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("enabled", BeEquivalentTo(1)))
+
+			By("releasing the event once the instance is back")
+			cronicleevent = getEvent()
+			cronicleevent.Spec.InstanceRef.Name = instanceName
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			sweep()
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("enabled", BeEquivalentTo(1)))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("k8s_owner_uid", BeEmpty()))
+		})
+
+		It("should adopt an orphaned event named by the annotation when the object is created again", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.DeletionPolicy = croniclenetv1.DeletionPolicyOrphan
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+
+			By("deleting the object with the Orphan policy")
+			spec := getEvent().Spec.DeepCopy()
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("creating it again with the adopt annotation")
+			recreated := &croniclenetv1.CronicleEvent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        resourceName,
+					Namespace:   "default",
+					Annotations: map[string]string{croniclenetv1.AdoptEventAnnotation: eventId},
+				},
+				Spec: *spec,
+			}
+			recreated.Spec.Title = "Migrated event"
+			Expect(k8sClient.Create(ctx, recreated)).To(Succeed())
+			Expect(reconcileCreated()).To(Equal(eventId))
+			Expect(cronicle.EventIDs()).To(ConsistOf(eventId))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Migrated event"))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("k8s_owner_uid", string(getEvent().UID)))
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonAdopted))
+		})
+
+		It("should not adopt an event owned by another CronicleEvent", func() {
+			eventId := reconcileCreated()
+
+			other := &croniclenetv1.CronicleEvent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "other-resource",
+					Namespace:   "default",
+					Annotations: map[string]string{croniclenetv1.AdoptEventAnnotation: eventId},
+				},
+				Spec: *getEvent().Spec.DeepCopy(),
+			}
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			defer func() {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+				other.Finalizers = nil
+				Expect(k8sClient.Update(ctx, other)).To(Succeed())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, other))).To(Succeed())
+			}()
+
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			Expect(other.Status.EventId).To(BeEmpty())
+			synced := meta.FindStatusCondition(other.Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonCreateFailed))
+			Expect(cronicle.EventIDs()).To(ConsistOf(eventId))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("k8s_owner_uid", string(getEvent().UID)))
+		})
+
+		It("should release the finalizer when the instance is gone and deletion is forced", func() {
+			eventId := reconcileCreated()
+			cronicleevent := getEvent()
+			cronicleevent.Spec.InstanceRef.Name = "missing"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			By("waiting for the instance")
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			result, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			deleting := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting).NotTo(BeNil())
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonInstanceUnavailable))
+
+			By("removing the finalizer once deletion is forced")
+			cronicleevent = getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.ForceDeleteAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(cronicle.Event(eventId)).NotTo(BeNil())
+		})
+
+		It("should release the finalizer when Cronicle is unreachable past the timeout", func() {
+			controllerReconciler.UnreachableInstanceTimeout = time.Nanosecond
+			reconcileCreated()
+			cronicle.Close()
+
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should count the unreachable timeout from the start of the outage", func() {
+			controllerReconciler.UnreachableInstanceTimeout = 2 * time.Second
+			eventId := reconcileCreated()
+			cronicle.StartJob(eventId)
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			deletingReason := func() string {
+				return meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting).Reason
+			}
+			setInstance := func(name string) {
+				cronicleevent := getEvent()
+				cronicleevent.Spec.InstanceRef.Name = name
+				Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			}
+
+			By("keeping the finalizer when the deletion is older than the timeout but the outage just started")
+			time.Sleep(controllerReconciler.UnreachableInstanceTimeout + 100*time.Millisecond)
+			setInstance("missing")
+			result, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(deletingReason()).To(Equal(croniclenetv1.ReasonInstanceUnavailable))
+
+			By("ending the outage once Cronicle answers again")
+			setInstance(instanceName)
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletingReason()).To(Equal(croniclenetv1.ReasonRunningJobs))
+
+			By("removing the finalizer once an outage lasted the timeout")
+			setInstance("missing")
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			cronicleevent := getEvent()
+			deleting := meta.FindStatusCondition(cronicleevent.Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonInstanceUnavailable))
+			deleting.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Minute))
+			Expect(k8sClient.Status().Update(ctx, cronicleevent)).To(Succeed())
+			_, err = reconcileEvent()
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should keep the finalizer of an event whose instance is misconfigured", func() {
+			controllerReconciler.UnreachableInstanceTimeout = time.Nanosecond
+			eventId := reconcileCreated()
+			instance := &croniclenetv1.CronicleInstance{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+			instance.Spec.APIKeySecretRef.Key = "missing"
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+
+			Expect(k8sClient.Delete(ctx, getEvent())).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).To(HaveOccurred())
+			deleting := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDeleting)
+			Expect(deleting.Reason).To(Equal(croniclenetv1.ReasonSecretNotFound))
+
+			By("removing it once deletion is forced")
+			cronicleevent := getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.ForceDeleteAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, typeNamespacedName, &croniclenetv1.CronicleEvent{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(cronicle.Event(eventId)).NotTo(BeNil())
+		})
+
+		It("should adopt an event created before its ID was recorded", func() {
+			cronicleevent := getEvent()
+			request := createEventRequest(&cronicleevent.Spec, cronicleevent.Spec.Params.Values())
+			request.OwnerUID = string(cronicleevent.UID)
+			leaked, err := cronicle_client.NewClient(cronicle.Config()).CreateEvent(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconcileCreated()).To(Equal(leaked))
+			Expect(cronicle.EventIDs()).To(ConsistOf(leaked))
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonAdopted))
+		})
+
+		It("should mark the events it creates with the UID of the object", func() {
+			eventId := reconcileCreated()
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("k8s_owner_uid", string(getEvent().UID)))
+		})
+
+		It("should correct changes made in Cronicle", func() {
+			eventId := reconcileCreated()
+
+			cronicle.SetEventField(eventId, "title", "Edited in the UI")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Test event"))
+			drifted := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDrifted)
+			Expect(drifted).NotTo(BeNil())
+			Expect(drifted.Reason).To(Equal(croniclenetv1.ReasonDriftCorrected))
+		})
+
+		It("should recreate an event deleted in Cronicle", func() {
+			eventId := reconcileCreated()
+
+			cronicle.RemoveEvent(eventId)
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			newEventId := getEvent().Status.EventId
+			Expect(newEventId).NotTo(Equal(eventId))
+			Expect(cronicle.Event(newEventId)).NotTo(BeNil())
+		})
+
+		It("should only report drift with the Report policy", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.DriftPolicy = croniclenetv1.DriftPolicyReport
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+
+			cronicle.SetEventField(eventId, "title", "Edited in the UI")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Edited in the UI"))
+			Expect(meta.IsStatusConditionTrue(getEvent().Status.Conditions, croniclenetv1.ConditionDrifted)).To(BeTrue())
+		})
+
+		It("should retry an update whose status cannot be recorded", func() {
+			eventId := reconcileCreated()
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Title = "Renamed event"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			controllerReconciler.Client = statusFailingClient{Client: k8sClient, fail: func() bool { return true }}
+			_, err := reconcileEvent()
+			Expect(err).To(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("title", "Renamed event"))
+
+			controllerReconciler.Client = k8sClient
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonUpdated))
+			Expect(getEvent().Status.LastHandledSpec.Title).To(Equal("Renamed event"))
+		})
+
+		It("should start one job for every new run-now value", func() {
+			eventId := reconcileCreated()
+
+			cronicleevent := getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.RunNowAnnotation: "1700000000"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			jobs := cronicle.ActiveJobs(eventId)
+			Expect(jobs).To(HaveLen(1))
+			status := getEvent().Status
+			Expect(status.RunNow).To(Equal("1700000000"))
+			Expect(status.RunNowJobIds).To(ConsistOf(jobs[0].ID))
+			Expect(meta.IsStatusConditionTrue(status.Conditions, croniclenetv1.ConditionRunNow)).To(BeTrue())
+		})
+
+		It("should not start a run-now job twice when the status cannot be written", func() {
+			eventId := reconcileCreated()
+			cronicleevent := getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.RunNowAnnotation: "1700000000"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			By("not starting a job when the request cannot be claimed")
+			controllerReconciler.Client = statusFailingClient{Client: k8sClient, fail: func() bool { return true }}
+			_, err := reconcileEvent()
+			Expect(err).To(HaveOccurred())
+			Expect(cronicle.Calls(cronicle_client.RunEventEndpoint)).To(BeZero())
+
+			By("starting it once when the status write after the run fails")
+			writes := 0
+			controllerReconciler.Client = statusFailingClient{Client: k8sClient, fail: func() bool {
+				writes++
+				return writes > 1
+			}}
+			_, err = reconcileEvent()
+			Expect(err).To(HaveOccurred())
+			Expect(cronicle.Calls(cronicle_client.RunEventEndpoint)).To(Equal(1))
+
+			controllerReconciler.Client = k8sClient
+			for i := 0; i < 2; i++ {
+				_, err = reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(cronicle.Calls(cronicle_client.RunEventEndpoint)).To(Equal(1))
+			Expect(cronicle.ActiveJobs(eventId)).To(HaveLen(1))
+			status := getEvent().Status
+			Expect(status.RunNow).To(Equal("1700000000"))
+			runNow := meta.FindStatusCondition(status.Conditions, croniclenetv1.ConditionRunNow)
+			Expect(runNow.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(runNow.Reason).To(Equal(croniclenetv1.ReasonRunFailed))
+		})
+
+		It("should report a run-now request refused by Cronicle", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.DriftPolicy = croniclenetv1.DriftPolicyIgnore
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+			events := controllerReconciler.Recorder.(*record.FakeRecorder).Events
+			Expect(events).To(Receive(HavePrefix("Normal " + croniclenetv1.ReasonCreated)))
+
+			By("requesting a run of an event removed from Cronicle")
+			cronicle.RemoveEvent(eventId)
+			cronicleevent = getEvent()
+			cronicleevent.Annotations = map[string]string{croniclenetv1.RunNowAnnotation: "1700000000"}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+
+			status := getEvent().Status
+			Expect(status.RunNow).To(Equal("1700000000"))
+			Expect(status.RunNowJobIds).To(BeEmpty())
+			runNow := meta.FindStatusCondition(status.Conditions, croniclenetv1.ConditionRunNow)
+			Expect(runNow).NotTo(BeNil())
+			Expect(runNow.Status).To(Equal(metav1.ConditionFalse))
+			Expect(runNow.Reason).To(Equal(croniclenetv1.ReasonRunFailed))
+			Expect(events).To(Receive(HavePrefix("Warning " + croniclenetv1.ReasonRunFailed)))
+		})
+
+		It("should record the finished jobs of the event", func() {
+			eventId := reconcileCreated()
+
+			succeeded := cronicle.StartJob(eventId)
+			cronicle.FinishJob(succeeded)
+			failed := cronicle.StartJob(eventId)
+			cronicle.CompleteJob(failed, 1, "Script exited with code: 1", "")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+
+			status := getEvent().Status
+			Expect(status.LastJobId).To(Equal(failed))
+			Expect(status.LastResult).To(Equal(croniclenetv1.JobResultFailed))
+			Expect(status.LastRun).NotTo(BeNil())
+			Expect(status.LastSuccessfulRun).NotTo(BeNil())
+			Expect(status.RecentJobs).To(HaveLen(2))
+			Expect(status.RecentJobs[1].JobId).To(Equal(succeeded))
+		})
+
+		It("should record Kubernetes events for the lifecycle of the event", func() {
+			events := controllerReconciler.Recorder.(*record.FakeRecorder).Events
+			eventId := reconcileCreated()
+			Expect(events).To(Receive(HavePrefix("Normal " + croniclenetv1.ReasonCreated)))
+
+			cronicle.SetEventField(eventId, "title", "Changed in the UI")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Receive(HavePrefix("Normal " + croniclenetv1.ReasonDriftCorrected)))
+
+			failed := cronicle.StartJob(eventId)
+			cronicle.CompleteJob(failed, 1, "Script exited with code: 1", "")
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Receive(And(HavePrefix("Warning "+croniclenetv1.ReasonJobFailed), ContainSubstring(failed))))
+		})
+
+		It("should record metrics of Cronicle calls and job outcomes", func() {
+			instance := "default/" + instanceName
+			creates := testutil.ToFloat64(cronicleRequestsTotal.WithLabelValues(cronicle_client.CreateEventEndpoint, instance, callResultSuccess))
+			failures := testutil.ToFloat64(eventJobsTotal.WithLabelValues("default", resourceName, croniclenetv1.JobResultFailed))
+
+			eventId := reconcileCreated()
+			Expect(testutil.ToFloat64(cronicleRequestsTotal.WithLabelValues(cronicle_client.CreateEventEndpoint, instance, callResultSuccess))).To(Equal(creates + 1))
+
+			failed := cronicle.StartJob(eventId)
+			cronicle.CompleteJob(failed, 1, "Script exited with code: 1", "")
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(testutil.ToFloat64(eventJobsTotal.WithLabelValues("default", resourceName, croniclenetv1.JobResultFailed))).To(Equal(failures + 1))
+
+			By("counting the events with the reader of the last setup")
+			eventsCollector.setReader(nil)
+			Expect(testutil.CollectAndCount(eventsCollector)).To(BeZero())
+			eventsCollector.setReader(k8sClient)
+			eventsCollector.setReader(k8sClient)
+			Expect(testutil.CollectAndCount(eventsCollector, "cronicle_operator_events")).To(BeNumerically(">=", 1))
+		})
+
+		It("should detect scheduled runs that did not succeed in time", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Timing = cronicle_client.CronicleTiming{}
+			cronicleevent.Spec.MissedRunGracePeriod = &metav1.Duration{Duration: time.Minute}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			eventId := reconcileCreated()
+			events := controllerReconciler.Recorder.(*record.FakeRecorder).Events
+			Expect(events).To(Receive(HavePrefix("Normal " + croniclenetv1.ReasonCreated)))
+
+			By("pretending the event was scheduled an hour ago")
+			cronicleevent = getEvent()
+			cronicleevent.Status.ScheduledSince = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+			Expect(k8sClient.Status().Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+
+			status := getEvent().Status
+			missed := meta.FindStatusCondition(status.Conditions, croniclenetv1.ConditionMissedSchedule)
+			Expect(missed).NotTo(BeNil())
+			Expect(missed.Status).To(Equal(metav1.ConditionTrue))
+			Expect(missed.Reason).To(Equal(croniclenetv1.ReasonRunNotStarted))
+			Expect(status.LastMissedRun).NotTo(BeNil())
+			Expect(events).To(Receive(ContainSubstring(croniclenetv1.ReasonRunNotStarted)))
+
+			By("running a job of the event")
+			cronicle.FinishJob(cronicle.StartJob(eventId))
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+
+			missed = meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionMissedSchedule)
+			Expect(missed.Status).To(Equal(metav1.ConditionFalse))
+			Expect(missed.Reason).To(Equal(croniclenetv1.ReasonOnSchedule))
+		})
+
+		It("should send the timing of a cron schedule", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Timing = cronicle_client.CronicleTiming{}
+			cronicleevent.Spec.Schedule = "15 */6 * * MON-FRI"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			eventId := reconcileCreated()
+			timing := cronicle.Event(eventId)["timing"]
+			Expect(timing).To(HaveKeyWithValue("minutes", ConsistOf(BeEquivalentTo(15))))
+			Expect(timing).To(HaveKeyWithValue("hours", ConsistOf(BeEquivalentTo(0), BeEquivalentTo(6), BeEquivalentTo(12), BeEquivalentTo(18))))
+			Expect(timing).To(HaveKeyWithValue("weekdays", HaveLen(5)))
+			Expect(timing).NotTo(HaveKey("days"))
+		})
+
+		It("should pass the parameters of other plugins to Cronicle", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Plugin = "urlplug"
+			cronicleevent.Spec.Params = croniclenetv1.EventParams{Custom: map[string]apiextensionsv1.JSON{
+				"url":     {Raw: []byte(`"https://example.com/hook"`)},
+				"timeout": {Raw: []byte(`30`)},
+				"headers": {Raw: []byte(`{"Accept":"application/json"}`)},
+			}}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			Expect(getEvent().Spec.Params.Custom).To(HaveLen(3))
+
+			eventId := reconcileCreated()
+			params := cronicle.Event(eventId)["params"]
+			Expect(params).To(HaveKeyWithValue("url", "https://example.com/hook"))
+			Expect(params).To(HaveKeyWithValue("timeout", BeEquivalentTo(30)))
+			Expect(params).To(HaveKeyWithValue("headers", HaveKeyWithValue("Accept", "application/json")))
+			Expect(params).NotTo(HaveKey("script"))
+
+			By("reporting no drift while the parameters match")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			drifted := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDrifted)
+			Expect(drifted).NotTo(BeNil())
+			Expect(drifted.Reason).To(Equal(croniclenetv1.ReasonNoDrift))
+		})
+
+		It("should read the script from a ConfigMap and follow its changes", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-script", Namespace: "default"},
+				Data:       map[string]string{"run.sh": "echo from configmap"},
+			}
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Params.Script = ""
+			cronicleevent.Spec.Params.ScriptFrom = &croniclenetv1.ScriptSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+					Key:                  "run.sh",
+				},
+			}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			By("waiting for the ConfigMap")
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced).NotTo(BeNil())
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonScriptNotFound))
+			Expect(cronicle.EventIDs()).To(BeEmpty())
+
+			By("creating the event with the script")
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, configMap)
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			eventId := getEvent().Status.EventId
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("params", HaveKeyWithValue("script", "echo from configmap")))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("params", Not(HaveKey("scriptFrom"))))
+
+			By("updating the event when the script changes")
+			configMap.Data["run.sh"] = "echo changed"
+			Expect(k8sClient.Update(ctx, configMap)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("params", HaveKeyWithValue("script", "echo changed")))
+			synced = meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonUpdated))
+		})
+
+		It("should render the script and parameters as templates", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-values", Namespace: "default"},
+				Data:       map[string]string{"region": "eu-west-1", "tenant": "default-tenant"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, configMap)
+
+			cronicleevent := getEvent()
+			cronicleevent.Labels = map[string]string{"team": "payments"}
+			cronicleevent.Spec.Params.Script = "echo {{ .Values.tenant }} {{ .Values.region }} {{ .Namespace }}/{{ .Name }} {{ .Labels.team }}"
+			cronicleevent.Spec.Params.Custom = map[string]apiextensionsv1.JSON{
+				"url": {Raw: []byte(`"{{ .InstanceURL }}/hook"`)},
+			}
+			cronicleevent.Spec.TemplateValues = map[string]string{"tenant": "acme"}
+			cronicleevent.Spec.TemplateValuesFrom = []croniclenetv1.TemplateValuesSource{{
+				ConfigMapRef: corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name}},
+			}}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			eventId := reconcileCreated()
+			params := cronicle.Event(eventId)["params"]
+			Expect(params).To(HaveKeyWithValue("script", "echo acme eu-west-1 default/test-resource payments"))
+			Expect(params).To(HaveKeyWithValue("url", cronicle.URL+"/hook"))
+
+			By("reporting templates that cannot be rendered")
+			cronicleevent = getEvent()
+			cronicleevent.Spec.Params.Script = "echo {{ .Values.missing }}"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced).NotTo(BeNil())
+			Expect(synced.Status).To(Equal(metav1.ConditionFalse))
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonRenderFailed))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("params", HaveKeyWithValue("script", "echo acme eu-west-1 default/test-resource payments")))
+		})
+
+		It("should inject secret environment variables and follow their rotation", func() {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-db", Namespace: "default"},
+				StringData: map[string]string{"password": "s3cret"},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, secret)
+
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Env = []croniclenetv1.EnvVar{
+				{Name: "DB_HOST", Value: "db.example.com"},
+				{Name: "DB_PASSWORD", ValueFrom: &croniclenetv1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+						Key:                  "password",
+					},
+				}},
+			}
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			eventId := reconcileCreated()
+			params := cronicle.Event(eventId)["params"]
+			Expect(params).To(HaveKeyWithValue("DB_HOST", "db.example.com"))
+			Expect(params).To(HaveKeyWithValue("DB_PASSWORD", "s3cret"))
+
+			By("keeping the secret out of the status")
+			status, err := json.Marshal(getEvent().Status)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(status)).NotTo(ContainSubstring("s3cret"))
+
+			By("updating the event when the secret is rotated")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+			secret.Data["password"] = []byte("rotated")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("params", HaveKeyWithValue("DB_PASSWORD", "rotated")))
+		})
+
+		It("should resolve the category, target and plugin names", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Category = ""
+			cronicleevent.Spec.CategoryName = "Backups"
+			cronicleevent.Spec.Target = ""
+			cronicleevent.Spec.TargetGroupName = "Master Group"
+			cronicleevent.Spec.Plugin = ""
+			cronicleevent.Spec.PluginName = "HTTP Request"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			By("reporting a name missing in Cronicle")
+			for i := 0; i < 2; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			synced := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionSynced)
+			Expect(synced).NotTo(BeNil())
+			Expect(synced.Status).To(Equal(metav1.ConditionFalse))
+			Expect(synced.Reason).To(Equal(croniclenetv1.ReasonNameNotFound))
+			Expect(synced.Message).To(ContainSubstring(`"Backups"`))
+			Expect(cronicle.EventIDs()).To(BeEmpty())
+
+			By("creating the event with the IDs of the names")
+			cronicle.AddCategory("backups", "Backups")
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			eventId := getEvent().Status.EventId
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("category", "backups"))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("target", "maingrp"))
+			Expect(cronicle.Event(eventId)).To(HaveKeyWithValue("plugin", "urlplug"))
+
+			By("reporting no drift while the IDs match")
+			_, err = reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			drifted := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionDrifted)
+			Expect(drifted).NotTo(BeNil())
+			Expect(drifted.Reason).To(Equal(croniclenetv1.ReasonNoDrift))
+		})
+
+		It("should not look names up again in steady state", func() {
+			cronicle.AddCategory("backups", "Backups")
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Category = ""
+			cronicleevent.Spec.CategoryName = "Backups"
+			cronicleevent.Spec.Target = ""
+			cronicleevent.Spec.TargetGroupName = "Master Group"
+			cronicleevent.Spec.Plugin = ""
+			cronicleevent.Spec.PluginName = "HTTP Request"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+			reconcileCreated()
+
+			lookups := func() []int {
+				return []int{
+					cronicle.Calls(cronicle_client.GetCategoriesEndpoint),
+					cronicle.Calls(cronicle_client.GetServerGroupsEndpoint),
+					cronicle.Calls(cronicle_client.GetPluginsEndpoint),
+				}
+			}
+			Expect(lookups()).To(Equal([]int{1, 1, 1}))
+			for i := 0; i < 3; i++ {
+				_, err := reconcileEvent()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(lookups()).To(Equal([]int{1, 1, 1}))
+		})
+
+		It("should require exactly one kind of target", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.TargetHostname = "worker1.example.com"
+			Expect(k8sClient.Update(ctx, cronicleevent)).NotTo(Succeed())
+		})
+
+		It("should report the next run and describe the schedule", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.Timing = cronicle_client.CronicleTiming{}
+			cronicleevent.Spec.Schedule = "30 2 * * *"
+			cronicleevent.Spec.Timezone = "UTC"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			reconcileCreated()
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+
+			status := getEvent().Status
+			Expect(status.ScheduleDescription).To(Equal("at 02:30"))
+			Expect(status.NextRunTime).NotTo(BeNil())
+			next := status.NextRunTime.UTC()
+			Expect(next.Hour()).To(Equal(2))
+			Expect(next.Minute()).To(Equal(30))
+			Expect(next).To(BeTemporally(">", time.Now()))
+			Expect(next).To(BeTemporally("<=", time.Now().Add(24*time.Hour)))
+		})
+
+		It("should report a missing instance", func() {
+			cronicleevent := getEvent()
+			cronicleevent.Spec.InstanceRef.Name = "missing"
+			Expect(k8sClient.Update(ctx, cronicleevent)).To(Succeed())
+
+			_, err := reconcileEvent()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileEvent()
+			Expect(err).To(HaveOccurred())
+			resolved := meta.FindStatusCondition(getEvent().Status.Conditions, croniclenetv1.ConditionInstanceResolved)
+			Expect(resolved).NotTo(BeNil())
+			Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+			Expect(resolved.Reason).To(Equal(croniclenetv1.ReasonInstanceNotFound))
+			Expect(cronicle.EventIDs()).To(BeEmpty())
 		})
 	})
 })
+
+// statusFailingClient fails the status writes for which fail returns true
+type statusFailingClient struct {
+	client.Client
+	fail func() bool
+}
+
+func (c statusFailingClient) Status() client.SubResourceWriter {
+	return statusFailingWriter{SubResourceWriter: c.Client.Status(), fail: c.fail}
+}
+
+type statusFailingWriter struct {
+	client.SubResourceWriter
+	fail func() bool
+}
+
+func (w statusFailingWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if w.fail() {
+		return errors.NewServiceUnavailable("status write failed")
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+// abortFailingAPI times out on every abort_job call
+type abortFailingAPI struct {
+	cronicle_client.CronicleAPI
+}
+
+func (a abortFailingAPI) AbortJob(ctx context.Context, jobID string) error {
+	return fmt.Errorf("%s: %w", cronicle_client.AbortJobEndpoint, context.DeadlineExceeded)
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// instanceRefIndex indexes CronicleEvents by the kind and name of the instance they reference
+const instanceRefIndex = ".spec.instanceRef"
+
+// instanceError is returned by resolveInstance and carries the InstanceResolved condition reason
+type instanceError struct {
+	reason string
+	err    error
+}
+
+func (e *instanceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *instanceError) Unwrap() error {
+	return e.err
+}
+
+// instanceFailureReason returns the condition reason of an error returned by resolveInstance
+func instanceFailureReason(err error) string {
+	var ie *instanceError
+	if errors.As(err, &ie) {
+		return ie.reason
+	}
+	return croniclenetv1.ReasonInvalidInstance
+}
+
+// notFoundReason picks reason for NotFound API errors and ReasonInvalidInstance otherwise
+func notFoundReason(err error, reason string) string {
+	if apierrors.IsNotFound(err) {
+		return reason
+	}
+	return croniclenetv1.ReasonInvalidInstance
+}
+
+// instanceRefKey returns the instanceRefIndex value of an instance
+func instanceRefKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// instanceResolver finds the Cronicle an event is bound to. It is shared by the reconcilers
+// that talk to the Cronicle of an event.
+type instanceResolver struct {
+	client.Client
+}
+
+// resolveInstance builds the client configuration for the Cronicle the event is bound to
+func (r instanceResolver) resolveInstance(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) (cronicle_client.Config, error) {
+	var config cronicle_client.Config
+	var err error
+
+	switch {
+	case cronicleEvent.Spec.InstanceRef != nil && cronicleEvent.Spec.InstanceRef.Kind == croniclenetv1.ClusterCronicleInstanceKind:
+		instance := &croniclenetv1.ClusterCronicleInstance{}
+		if err := r.Get(ctx, types.NamespacedName{Name: cronicleEvent.Spec.InstanceRef.Name}, instance); err != nil {
+			return config, &instanceError{
+				reason: notFoundReason(err, croniclenetv1.ReasonInstanceNotFound),
+				err:    fmt.Errorf("failed to get ClusterCronicleInstance %s: %w", cronicleEvent.Spec.InstanceRef.Name, err),
+			}
+		}
+		allowed, err := r.namespaceAllowed(ctx, instance.Spec.AllowedNamespaces, cronicleEvent.Namespace)
+		if err != nil {
+			return config, err
+		}
+		if !allowed {
+			return config, &instanceError{
+				reason: croniclenetv1.ReasonNamespaceNotAllowed,
+				err:    fmt.Errorf("ClusterCronicleInstance %s does not allow namespace %s", instance.Name, cronicleEvent.Namespace),
+			}
+		}
+		config, err = r.instanceConfig(ctx, instance.Spec.Namespace, &instance.Spec.CronicleInstanceSpec)
+		if err != nil {
+			return config, err
+		}
+	case cronicleEvent.Spec.InstanceRef != nil:
+		instance := &croniclenetv1.CronicleInstance{}
+		key := types.NamespacedName{Name: cronicleEvent.Spec.InstanceRef.Name, Namespace: cronicleEvent.Namespace}
+		if err := r.Get(ctx, key, instance); err != nil {
+			return config, &instanceError{
+				reason: notFoundReason(err, croniclenetv1.ReasonInstanceNotFound),
+				err:    fmt.Errorf("failed to get CronicleInstance %s: %w", key, err),
+			}
+		}
+		config, err = r.instanceConfig(ctx, instance.Namespace, &instance.Spec)
+		if err != nil {
+			return config, err
+		}
+	case cronicleEvent.Spec.InstanceSelector != nil:
+		service, err := r.getFirstMatchingService(ctx, cronicleEvent.Namespace, cronicleEvent.Spec.InstanceSelector)
+		if err != nil {
+			return config, err
+		}
+		if len(service.Spec.Ports) == 0 {
+			return config, &instanceError{
+				reason: croniclenetv1.ReasonNoMatchingService,
+				err:    fmt.Errorf("service %s/%s has no ports", service.Namespace, service.Name),
+			}
+		}
+		config = cronicle_client.Config{
+			BaseUrl:       fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port),
+			APIKey:        os.Getenv("CRONICLE_API_KEY"),
+			Timeout:       10 * time.Second,
+			RetryAttempts: 2,
+		}
+	default:
+		return config, &instanceError{reason: croniclenetv1.ReasonInstanceNotSet, err: errors.New("spec.instanceRef is not set")}
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// instanceConfig builds the client configuration of an instance whose Service and Secret live in namespace
+func (r instanceResolver) instanceConfig(ctx context.Context, namespace string, spec *croniclenetv1.CronicleInstanceSpec) (cronicle_client.Config, error) {
+	baseUrl, err := r.instanceBaseUrl(ctx, namespace, spec)
+	if err != nil {
+		return cronicle_client.Config{}, err
+	}
+	apiKey, err := r.secretValue(ctx, namespace, spec.APIKeySecretRef)
+	if err != nil {
+		return cronicle_client.Config{}, err
+	}
+	return cronicle_client.Config{
+		BaseUrl:       baseUrl,
+		APIKey:        apiKey,
+		Timeout:       spec.Timeout.Duration,
+		RetryAttempts: spec.RetryAttempts,
+	}, nil
+}
+
+// namespaceAllowed checks the labels of a namespace against an allowedNamespaces selector
+func (r instanceResolver) namespaceAllowed(ctx context.Context, allowedNamespaces *metav1.LabelSelector, namespace string) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(allowedNamespaces)
+	if err != nil {
+		return false, err
+	}
+	if selector.Empty() {
+		return true, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// instanceBaseUrl returns the Cronicle API base URL described by an instance spec
+func (r instanceResolver) instanceBaseUrl(ctx context.Context, namespace string, spec *croniclenetv1.CronicleInstanceSpec) (string, error) {
+	if spec.URL != "" {
+		return strings.TrimSuffix(spec.URL, "/"), nil
+	}
+	if spec.ServiceRef == nil {
+		return "", errors.New("either url or serviceRef must be set on the instance")
+	}
+
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: spec.ServiceRef.Name, Namespace: namespace}, service); err != nil {
+		return "", &instanceError{
+			reason: notFoundReason(err, croniclenetv1.ReasonNoMatchingService),
+			err:    fmt.Errorf("failed to get service %s/%s: %w", namespace, spec.ServiceRef.Name, err),
+		}
+	}
+
+	var port int32
+	for _, p := range service.Spec.Ports {
+		if spec.ServiceRef.Port == "" || p.Name == spec.ServiceRef.Port {
+			port = p.Port
+			break
+		}
+	}
+	if port == 0 {
+		return "", fmt.Errorf("service %s/%s has no port named %q", namespace, service.Name, spec.ServiceRef.Port)
+	}
+
+	scheme := spec.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s.%s.svc.cluster.local:%d", scheme, service.Name, service.Namespace, port), nil
+}
+
+// secretValue reads a single key of a Secret
+func (r instanceResolver) secretValue(ctx context.Context, namespace string, selector corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return "", &instanceError{
+			reason: notFoundReason(err, croniclenetv1.ReasonSecretNotFound),
+			err:    fmt.Errorf("failed to get secret %s/%s: %w", namespace, selector.Name, err),
+		}
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", &instanceError{
+			reason: croniclenetv1.ReasonSecretNotFound,
+			err:    fmt.Errorf("secret %s/%s has no key %q", namespace, selector.Name, selector.Key),
+		}
+	}
+	return string(value), nil
+}
+
+func (r instanceResolver) getFirstMatchingService(ctx context.Context, namespace string, instanceSelector *metav1.LabelSelector) (*corev1.Service, error) {
+	// Convert to selector
+	selector, err := metav1.LabelSelectorAsSelector(instanceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceList := &corev1.ServiceList{}
+	listOptions := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: selector,
+	}
+	err = r.List(ctx, serviceList, listOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serviceList.Items) == 0 {
+		return nil, &instanceError{reason: croniclenetv1.ReasonNoMatchingService, err: errors.New("no matching services found")}
+	}
+
+	// Return the first matching service
+	return &serviceList.Items[0], nil
+}
+
+// eventsForInstance maps a CronicleInstance to the CronicleEvents referencing it
+func (r *CronicleEventReconciler) eventsForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.eventsReferencing(ctx,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{instanceRefIndex: instanceRefKey(croniclenetv1.CronicleInstanceKind, obj.GetName())},
+	)
+}
+
+// eventsForClusterInstance maps a ClusterCronicleInstance to the CronicleEvents referencing it
+func (r *CronicleEventReconciler) eventsForClusterInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.eventsReferencing(ctx,
+		client.MatchingFields{instanceRefIndex: instanceRefKey(croniclenetv1.ClusterCronicleInstanceKind, obj.GetName())},
+	)
+}
+
+// eventsForNamespace maps a Namespace to its CronicleEvents using a ClusterCronicleInstance, whose
+// allowedNamespaces may select the namespace once its labels change
+func (r *CronicleEventReconciler) eventsForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	events := &croniclenetv1.CronicleEventList{}
+	if err := r.List(ctx, events, client.InNamespace(obj.GetName())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, item := range events.Items {
+		if item.Spec.InstanceRef == nil || item.Spec.InstanceRef.Kind != croniclenetv1.ClusterCronicleInstanceKind {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
+
+func (r *CronicleEventReconciler) eventsReferencing(ctx context.Context, opts ...client.ListOption) []reconcile.Request {
+	events := &croniclenetv1.CronicleEventList{}
+	if err := r.List(ctx, events, opts...); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(events.Items))
+	for _, item := range events.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client/fake"
+)
+
+var _ = Describe("Instance resolution", func() {
+	ctx := context.Background()
+
+	// create creates an object that is deleted when the spec ends
+	create := func(obj client.Object) {
+		Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, obj)
+	}
+
+	eventFor := func(ref *croniclenetv1.InstanceReference) *croniclenetv1.CronicleEvent {
+		return &croniclenetv1.CronicleEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: "resolved-event", Namespace: "default"},
+			Spec:       croniclenetv1.CronicleEventSpec{InstanceRef: ref},
+		}
+	}
+
+	resolve := func(cronicleEvent *croniclenetv1.CronicleEvent) (cronicle_client.Config, error) {
+		return instanceResolver{k8sClient}.resolveInstance(ctx, cronicleEvent)
+	}
+
+	BeforeEach(func() {
+		create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "resolve-api-key", Namespace: "default"},
+			StringData: map[string]string{"apiKey": "secret-key"},
+		})
+	})
+
+	instance := func(name, key string) *croniclenetv1.CronicleInstance {
+		return &croniclenetv1.CronicleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: croniclenetv1.CronicleInstanceSpec{
+				URL: "http://cronicle.example.com:3012/",
+				APIKeySecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "resolve-api-key"},
+					Key:                  key,
+				},
+				Timeout:       metav1.Duration{Duration: 10 * time.Second},
+				RetryAttempts: 2,
+			},
+		}
+	}
+
+	It("should resolve a CronicleInstance in the namespace of the event", func() {
+		create(instance("resolve-instance", "apiKey"))
+
+		config, err := resolve(eventFor(&croniclenetv1.InstanceReference{Name: "resolve-instance"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.BaseUrl).To(Equal("http://cronicle.example.com:3012"))
+		Expect(config.APIKey).To(Equal("secret-key"))
+	})
+
+	It("should report a missing instance", func() {
+		_, err := resolve(eventFor(&croniclenetv1.InstanceReference{Name: "no-such-instance"}))
+		Expect(err).To(HaveOccurred())
+		Expect(instanceFailureReason(err)).To(Equal(croniclenetv1.ReasonInstanceNotFound))
+	})
+
+	It("should report a missing API key in the Secret", func() {
+		create(instance("resolve-wrong-key", "token"))
+
+		_, err := resolve(eventFor(&croniclenetv1.InstanceReference{Name: "resolve-wrong-key"}))
+		Expect(err).To(MatchError(ContainSubstring(`has no key "token"`)))
+		Expect(instanceFailureReason(err)).To(Equal(croniclenetv1.ReasonSecretNotFound))
+	})
+
+	It("should refuse a selected Service without ports", func() {
+		create(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "resolve-headless", Namespace: "default", Labels: map[string]string{"app": "resolve-headless"}},
+			Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+		})
+
+		cronicleEvent := eventFor(nil)
+		cronicleEvent.Spec.InstanceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "resolve-headless"}}
+		_, err := resolve(cronicleEvent)
+		Expect(err).To(MatchError(ContainSubstring("has no ports")))
+		Expect(instanceFailureReason(err)).To(Equal(croniclenetv1.ReasonNoMatchingService))
+	})
+})
+
+var _ = Describe("ClusterCronicleInstance", func() {
+	const namespaceName = "cluster-instance-tenant"
+	const instanceName = "shared-cronicle"
+
+	ctx := context.Background()
+	eventKey := types.NamespacedName{Name: "tenant-event", Namespace: namespaceName}
+
+	var cronicle *fake.Server
+	var controllerReconciler *CronicleEventReconciler
+
+	reconcileEvent := func() {
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: eventKey})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	resolvedCondition := func() *metav1.Condition {
+		cronicleEvent := &croniclenetv1.CronicleEvent{}
+		Expect(k8sClient.Get(ctx, eventKey, cronicleEvent)).To(Succeed())
+		return meta.FindStatusCondition(cronicleEvent.Status.Conditions, croniclenetv1.ConditionInstanceResolved)
+	}
+
+	setTeam := func(team string) {
+		namespace := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		namespace.Labels = map[string]string{"team": team}
+		Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		cronicle = fake.NewServer()
+		DeferCleanup(cronicle.Close)
+		controllerReconciler = &CronicleEventReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+		setTeam("other")
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-api-key", Namespace: "default"},
+			StringData: map[string]string{"apiKey": fake.APIKey},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, secret)
+
+		instance := &croniclenetv1.ClusterCronicleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+			Spec: croniclenetv1.ClusterCronicleInstanceSpec{
+				CronicleInstanceSpec: croniclenetv1.CronicleInstanceSpec{
+					URL: cronicle.URL,
+					APIKeySecretRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+						Key:                  "apiKey",
+					},
+				},
+				Namespace:         "default",
+				AllowedNamespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, instance)
+
+		cronicleEvent := &croniclenetv1.CronicleEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: eventKey.Name, Namespace: eventKey.Namespace},
+			Spec: croniclenetv1.CronicleEventSpec{
+				Category:    "general",
+				Enabled:     1,
+				Plugin:      "shellplug",
+				Target:      "allgrp",
+				Timezone:    "Europe/Istanbul",
+				Title:       "Tenant event",
+				InstanceRef: &croniclenetv1.InstanceReference{Name: instanceName, Kind: croniclenetv1.ClusterCronicleInstanceKind},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cronicleEvent)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Get(ctx, eventKey, cronicleEvent)).To(Succeed())
+			cronicleEvent.Finalizers = nil
+			Expect(k8sClient.Update(ctx, cronicleEvent)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, cronicleEvent))).To(Succeed())
+		})
+	})
+
+	It("should create the events of an allowed namespace", func() {
+		setTeam("payments")
+		reconcileEvent()
+		reconcileEvent()
+
+		resolved := resolvedCondition()
+		Expect(resolved).NotTo(BeNil())
+		Expect(resolved.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cronicle.EventIDs()).To(HaveLen(1))
+	})
+
+	It("should allow every namespace with an empty selector and none without one", func() {
+		setAllowedNamespaces := func(selector *metav1.LabelSelector) {
+			instance := &croniclenetv1.ClusterCronicleInstance{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName}, instance)).To(Succeed())
+			instance.Spec.AllowedNamespaces = selector
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+		}
+
+		setAllowedNamespaces(nil)
+		reconcileEvent()
+		reconcileEvent()
+		Expect(resolvedCondition().Reason).To(Equal(croniclenetv1.ReasonNamespaceNotAllowed))
+
+		setAllowedNamespaces(&metav1.LabelSelector{})
+		reconcileEvent()
+		Expect(resolvedCondition().Status).To(Equal(metav1.ConditionTrue))
+		Expect(cronicle.EventIDs()).To(HaveLen(1))
+	})
+
+	It("should refuse a namespace until its labels are allowed", func() {
+		reconcileEvent()
+		reconcileEvent()
+
+		resolved := resolvedCondition()
+		Expect(resolved).NotTo(BeNil())
+		Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+		Expect(resolved.Reason).To(Equal(croniclenetv1.ReasonNamespaceNotAllowed))
+		Expect(cronicle.EventIDs()).To(BeEmpty())
+
+		By("relabeling the namespace")
+		setTeam("payments")
+		namespace := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(controllerReconciler.eventsForNamespace(ctx, namespace)).To(ConsistOf(reconcile.Request{NamespacedName: eventKey}))
+		reconcileEvent()
+
+		Expect(resolvedCondition().Status).To(Equal(metav1.ConditionTrue))
+		Expect(cronicle.EventIDs()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,286 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+const (
+	// defaultJobPollInterval is used when CronicleJobRunReconciler.PollInterval is not set
+	defaultJobPollInterval = 10 * time.Second
+	// logExcerptBytes is how much of the end of the job log is kept in the status
+	logExcerptBytes = 4096
+	// eventRefIndex indexes CronicleJobRuns by the name of the event they run
+	eventRefIndex = ".spec.eventRef.name"
+)
+
+// CronicleJobRunReconciler reconciles a CronicleJobRun object
+type CronicleJobRunReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// PollInterval is how often the status of a running job is fetched from Cronicle
+	PollInterval time.Duration
+
+	// ClientFactory builds the Cronicle client of an instance, cronicle_client.NewCronicleAPI when nil
+	ClientFactory cronicle_client.ClientFactory
+}
+
+// +kubebuilder:rbac:groups=cronicle.net,resources=croniclejobruns,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cronicle.net,resources=croniclejobruns/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cronicle.net,resources=cronicleevents,verbs=get;list;watch
+
+// Reconcile starts the job of a CronicleJobRun once and follows it until it finishes
+func (r *CronicleJobRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	jobRun := &croniclenetv1.CronicleJobRun{}
+	if err := r.Get(ctx, req.NamespacedName, jobRun); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if jobRun.Status.Phase == croniclenetv1.JobRunPhaseSucceeded || jobRun.Status.Phase == croniclenetv1.JobRunPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	cronicleEvent := &croniclenetv1.CronicleEvent{}
+	key := types.NamespacedName{Name: jobRun.Spec.EventRef.Name, Namespace: jobRun.Namespace}
+	if err := r.Get(ctx, key, cronicleEvent); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// The event watch requeues the run once the event shows up
+		return r.setPending(ctx, jobRun, croniclenetv1.ReasonEventNotFound, fmt.Sprintf("CronicleEvent %s not found", key))
+	}
+	if cronicleEvent.Status.EventId == "" || cronicleEvent.GetDeletionTimestamp() != nil {
+		return r.setPending(ctx, jobRun, croniclenetv1.ReasonEventNotReady, fmt.Sprintf("CronicleEvent %s is not created in Cronicle", key))
+	}
+
+	clientConfig, err := instanceResolver{r.Client}.resolveInstance(ctx, cronicleEvent)
+	if err != nil {
+		if _, statusErr := r.setPending(ctx, jobRun, instanceFailureReason(err), err.Error()); statusErr != nil {
+			l.Error(statusErr, "Failed to update status")
+		}
+		return ctrl.Result{}, err
+	}
+	cronicleClient, err := newCronicleClient(r.ClientFactory, clientConfig, instanceLabel(cronicleEvent))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if jobRun.Status.JobId == "" {
+		return r.startJob(ctx, cronicleClient, jobRun, cronicleEvent)
+	}
+	return r.trackJob(ctx, cronicleClient, jobRun)
+}
+
+// startJob calls run_event. It is never retried: a failed request may still have started a
+// job, and running it twice is worse than asking for a new CronicleJobRun. The run is first
+// moved to Running so that a stale copy of it cannot start a second job.
+func (r *CronicleJobRunReconciler) startJob(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, jobRun *croniclenetv1.CronicleJobRun, cronicleEvent *croniclenetv1.CronicleEvent) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	if jobRun.Status.Phase == croniclenetv1.JobRunPhaseRunning {
+		// A previous attempt stopped between claiming the run and recording the job
+		r.finish(jobRun, croniclenetv1.JobRunPhaseFailed, croniclenetv1.ReasonRunFailed, "Interrupted while starting the job, it may or may not have been started")
+		return ctrl.Result{}, r.Status().Update(ctx, jobRun)
+	}
+	now := metav1.Now()
+	jobRun.Status.Phase = croniclenetv1.JobRunPhaseRunning
+	jobRun.Status.EventId = cronicleEvent.Status.EventId
+	jobRun.Status.StartTime = &now
+	if err := r.Status().Update(ctx, jobRun); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	jobIds, err := cronicleClient.RunEvent(ctx, runEventRequest(cronicleEvent.Status.EventId, &jobRun.Spec))
+	if err == nil && len(jobIds) == 0 {
+		err = fmt.Errorf("%s: no job was started", cronicle_client.RunEventEndpoint)
+	}
+	if err != nil {
+		l.Error(err, "Failed to run event", "eventId", cronicleEvent.Status.EventId)
+		r.finish(jobRun, croniclenetv1.JobRunPhaseFailed, apiFailureReason(err, croniclenetv1.ReasonRunFailed), err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, jobRun)
+	}
+
+	l.Info("Job started", "eventId", cronicleEvent.Status.EventId, "jobIds", jobIds)
+	jobRun.Status.JobId = jobIds[0]
+	jobRun.Status.JobIds = jobIds
+	setJobRunCondition(jobRun, metav1.ConditionFalse, croniclenetv1.ReasonJobRunning, "Job "+jobIds[0]+" is running")
+	if err := r.Status().Update(ctx, jobRun); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+}
+
+// trackJob polls the status of the started job and records its result once it completed
+func (r *CronicleJobRunReconciler) trackJob(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, jobRun *croniclenetv1.CronicleJobRun) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	job, err := cronicleClient.GetJobStatus(ctx, jobRun.Status.JobId)
+	if cronicle_client.IsNotFound(err) {
+		r.finish(jobRun, croniclenetv1.JobRunPhaseFailed, croniclenetv1.ReasonJobNotFound, err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, jobRun)
+	}
+	if err != nil {
+		l.Error(err, "Failed to get job status", "jobId", jobRun.Status.JobId)
+		if cronicle_client.IsTransient(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+	}
+	if job.Complete != 1 {
+		return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+	}
+
+	if job.TimeStart > 0 {
+		jobRun.Status.StartTime = unixTime(job.TimeStart)
+	}
+	jobRun.Status.ExitCode = string(job.Code)
+	if jobRun.Status.ExitCode == "" {
+		jobRun.Status.ExitCode = "0"
+	}
+	jobRun.Status.Description = job.Description
+
+	logExcerpt, err := cronicleClient.GetJobLog(ctx, job.Id, logExcerptBytes)
+	if err != nil {
+		l.Error(err, "Failed to get job log", "jobId", job.Id)
+	}
+	jobRun.Status.LogExcerpt = logExcerpt
+
+	if job.Succeeded() {
+		r.finish(jobRun, croniclenetv1.JobRunPhaseSucceeded, croniclenetv1.ReasonJobSucceeded, "Job "+job.Id+" succeeded")
+	} else {
+		r.finish(jobRun, croniclenetv1.JobRunPhaseFailed, croniclenetv1.ReasonJobFailed, fmt.Sprintf("Job %s failed with code %s: %s", job.Id, job.Code, job.Description))
+	}
+	if job.TimeEnd > 0 {
+		jobRun.Status.CompletionTime = unixTime(job.TimeEnd)
+	}
+	l.Info("Job finished", "jobId", job.Id, "phase", jobRun.Status.Phase)
+	return ctrl.Result{}, r.Status().Update(ctx, jobRun)
+}
+
+// setPending records why the job was not started yet. Runs that already started keep their
+// phase and are looked at again by the next poll.
+func (r *CronicleJobRunReconciler) setPending(ctx context.Context, jobRun *croniclenetv1.CronicleJobRun, reason, message string) (ctrl.Result, error) {
+	if jobRun.Status.Phase == croniclenetv1.JobRunPhaseRunning {
+		log.FromContext(ctx).Info("Cannot follow the job", "reason", reason, "message", message)
+		return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+	}
+	changed := jobRun.Status.Phase != croniclenetv1.JobRunPhasePending
+	jobRun.Status.Phase = croniclenetv1.JobRunPhasePending
+	if setJobRunCondition(jobRun, metav1.ConditionFalse, reason, message) || changed {
+		return ctrl.Result{}, r.Status().Update(ctx, jobRun)
+	}
+	return ctrl.Result{}, nil
+}
+
+// finish moves the run to its final phase
+func (r *CronicleJobRunReconciler) finish(jobRun *croniclenetv1.CronicleJobRun, phase, reason, message string) {
+	now := metav1.Now()
+	jobRun.Status.Phase = phase
+	jobRun.Status.CompletionTime = &now
+	setJobRunCondition(jobRun, metav1.ConditionTrue, reason, message)
+}
+
+func (r *CronicleJobRunReconciler) pollInterval() time.Duration {
+	if r.PollInterval > 0 {
+		return r.PollInterval
+	}
+	return defaultJobPollInterval
+}
+
+// setJobRunCondition records the Complete condition and reports whether it changed
+func setJobRunCondition(jobRun *croniclenetv1.CronicleJobRun, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(&jobRun.Status.Conditions, metav1.Condition{
+		Type:               croniclenetv1.ConditionComplete,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: jobRun.Generation,
+	})
+}
+
+// unixTime converts a Cronicle timestamp with fractional seconds. The fraction is dropped
+// since metav1.Time is serialized with second precision.
+func unixTime(seconds float64) *metav1.Time {
+	t := metav1.NewTime(time.Unix(int64(seconds), 0))
+	return &t
+}
+
+// runEventRequest builds the run_event payload of a CronicleJobRun
+func runEventRequest(eventId string, spec *croniclenetv1.CronicleJobRunSpec) cronicle_client.RunEventRequest {
+	request := cronicle_client.RunEventRequest{Id: eventId}
+	if spec.Script == "" && len(spec.Params) == 0 {
+		return request
+	}
+	request.Params = map[string]interface{}{}
+	for key, value := range spec.Params {
+		request.Params[key] = value
+	}
+	if spec.Script != "" {
+		request.Params["script"] = spec.Script
+	}
+	return request
+}
+
+// jobRunsForEvent maps a CronicleEvent to the unfinished CronicleJobRuns waiting for it
+func (r *CronicleJobRunReconciler) jobRunsForEvent(ctx context.Context, obj client.Object) []reconcile.Request {
+	jobRuns := &croniclenetv1.CronicleJobRunList{}
+	if err := r.List(ctx, jobRuns, client.InNamespace(obj.GetNamespace()), client.MatchingFields{eventRefIndex: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list CronicleJobRuns for event", "event", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, jobRun := range jobRuns.Items {
+		if jobRun.Status.Phase == "" || jobRun.Status.Phase == croniclenetv1.JobRunPhasePending {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&jobRun)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CronicleJobRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &croniclenetv1.CronicleJobRun{}, eventRefIndex, func(obj client.Object) []string {
+		return []string{obj.(*croniclenetv1.CronicleJobRun).Spec.EventRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&croniclenetv1.CronicleJobRun{}).
+		Watches(&croniclenetv1.CronicleEvent{}, handler.EnqueueRequestsFromMapFunc(r.jobRunsForEvent)).
+		Complete(r)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client/fake"
+)
+
+var _ = Describe("CronicleJobRun Controller", func() {
+	Context("When reconciling a resource", func() {
+		const eventName = "jobrun-event"
+		const instanceName = "jobrun-instance"
+		const secretName = "jobrun-api-key"
+
+		ctx := context.Background()
+
+		var cronicle *fake.Server
+		var eventId string
+
+		reconcileJobRun := func(name string) *croniclenetv1.CronicleJobRun {
+			key := types.NamespacedName{Name: name, Namespace: "default"}
+			_, err := (&CronicleJobRunReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}).Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			jobRun := &croniclenetv1.CronicleJobRun{}
+			Expect(k8sClient.Get(ctx, key, jobRun)).To(Succeed())
+			return jobRun
+		}
+
+		createJobRun := func(name, event string) {
+			jobRun := &croniclenetv1.CronicleJobRun{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: croniclenetv1.CronicleJobRunSpec{
+					EventRef: corev1.LocalObjectReference{Name: event},
+					Script:   "#!/bin/bash\necho override",
+					Params:   map[string]string{"annotate": "1"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, jobRun)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, jobRun)).To(Succeed())
+			})
+		}
+
+		BeforeEach(func() {
+			cronicle = fake.NewServer()
+			DeferCleanup(cronicle.Close)
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+				StringData: map[string]string{"apiKey": fake.APIKey},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, secret)
+
+			instance := &croniclenetv1.CronicleInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: croniclenetv1.CronicleInstanceSpec{
+					URL: cronicle.URL,
+					APIKeySecretRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Key:                  "apiKey",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, instance)
+
+			By("creating an event that already exists in Cronicle")
+			var err error
+			eventId, err = cronicle_client.NewClient(cronicle.Config()).CreateEvent(ctx, createEventRequest(&croniclenetv1.CronicleEventSpec{
+				Category: "general",
+				Plugin:   "shellplug",
+				Target:   "allgrp",
+				Title:    "Job run event",
+			}, nil))
+			Expect(err).NotTo(HaveOccurred())
+			cronicleEvent := &croniclenetv1.CronicleEvent{
+				ObjectMeta: metav1.ObjectMeta{Name: eventName, Namespace: "default"},
+				Spec: croniclenetv1.CronicleEventSpec{
+					Category:    "general",
+					Enabled:     1,
+					Plugin:      "shellplug",
+					Target:      "allgrp",
+					Title:       "Job run event",
+					InstanceRef: &croniclenetv1.InstanceReference{Name: instanceName},
+				},
+			}
+			Expect(k8sClient.Create(ctx, cronicleEvent)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, cronicleEvent)
+			cronicleEvent.Status.EventId = eventId
+			cronicleEvent.Status.EventStatus = "created"
+			Expect(k8sClient.Status().Update(ctx, cronicleEvent)).To(Succeed())
+		})
+
+		It("should run the event once and record the result of the job", func() {
+			createJobRun("test-run", eventName)
+
+			By("starting the job with the overrides")
+			jobRun := reconcileJobRun("test-run")
+			Expect(jobRun.Status.Phase).To(Equal(croniclenetv1.JobRunPhaseRunning))
+			jobs := cronicle.ActiveJobs(eventId)
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobRun.Status.JobId).To(Equal(jobs[0].ID))
+			Expect(jobs[0].Params).To(HaveKeyWithValue("script", "#!/bin/bash\necho override"))
+			Expect(jobs[0].Params).To(HaveKeyWithValue("annotate", "1"))
+
+			By("not starting another job while it runs")
+			jobRun = reconcileJobRun("test-run")
+			Expect(jobRun.Status.Phase).To(Equal(croniclenetv1.JobRunPhaseRunning))
+			Expect(cronicle.Calls(cronicle_client.RunEventEndpoint)).To(Equal(1))
+
+			By("recording the result once the job finished")
+			cronicle.CompleteJob(jobs[0].ID, 2, "Script exited with code: 2", "starting\nfailed\n")
+			jobRun = reconcileJobRun("test-run")
+			Expect(jobRun.Status.Phase).To(Equal(croniclenetv1.JobRunPhaseFailed))
+			Expect(jobRun.Status.ExitCode).To(Equal("2"))
+			Expect(jobRun.Status.Description).To(Equal("Script exited with code: 2"))
+			Expect(jobRun.Status.LogExcerpt).To(Equal("starting\nfailed\n"))
+			Expect(jobRun.Status.CompletionTime).NotTo(BeNil())
+			Expect(meta.IsStatusConditionTrue(jobRun.Status.Conditions, croniclenetv1.ConditionComplete)).To(BeTrue())
+		})
+
+		It("should wait for a missing event", func() {
+			createJobRun("missing-event-run", "missing")
+
+			jobRun := reconcileJobRun("missing-event-run")
+			Expect(jobRun.Status.Phase).To(Equal(croniclenetv1.JobRunPhasePending))
+			complete := meta.FindStatusCondition(jobRun.Status.Conditions, croniclenetv1.ConditionComplete)
+			Expect(complete).NotTo(BeNil())
+			Expect(complete.Reason).To(Equal(croniclenetv1.ReasonEventNotFound))
+			Expect(cronicle.Calls(cronicle_client.RunEventEndpoint)).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,271 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// releaseEvent removes the finalizer of a CronicleEvent with the Disable or Orphan deletion
+// policy and leaves its event in Cronicle. The event is disabled first for the Disable policy.
+// Either way its ownership mark is removed so that the orphan sweeper leaves it alone, the
+// finalizer is kept until that succeeds.
+func (r *CronicleEventReconciler) releaseEvent(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	eventId := cronicleEvent.Status.EventId
+	policy := cronicleEvent.Spec.DeletionPolicy
+
+	reason := croniclenetv1.ReasonDisableFailed
+	var err error
+	if policy == croniclenetv1.DeletionPolicyDisable {
+		err = cronicleClient.DisableEvent(ctx, eventId)
+	}
+	if err == nil {
+		reason = croniclenetv1.ReasonReleaseFailed
+		err = cronicleClient.ReleaseEvent(ctx, eventId)
+	}
+	if err != nil && !cronicle_client.IsNotFound(err) {
+		l.Error(err, "Failed to release event", "eventId", eventId, "deletionPolicy", policy)
+		if cronicle_client.IsTransient(err) {
+			return r.instanceUnavailable(ctx, cronicleEvent, err)
+		}
+		reason = apiFailureReason(err, reason)
+		setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, reason, err.Error())
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to release event %s: %v", eventId, err)
+		if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+			l.Error(statusErr, "Failed to update status")
+		}
+		return r.apiErrorResult(err)
+	}
+	l.Info("Event left in Cronicle", "eventId", eventId, "deletionPolicy", policy)
+	r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonReleased, "Event %s left in Cronicle by the %s deletion policy", eventId, policy)
+
+	return r.removeFinalizer(ctx, cronicleEvent)
+}
+
+// removeFinalizer lets Kubernetes delete the CronicleEvent
+func (r *CronicleEventReconciler) removeFinalizer(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) (ctrl.Result, error) {
+	controllerutil.RemoveFinalizer(cronicleEvent, "cronicle.net/eventfinalizer")
+	forgetEventMetrics(cronicleEvent)
+	if err := r.Update(ctx, cronicleEvent); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// instanceResolveFailed handles the deletion of an event whose instance cannot be resolved. A
+// missing instance or Service is waited for like an unreachable Cronicle, other failures such
+// as a missing Secret key or a refused namespace block the deletion until they are fixed.
+func (r *CronicleEventReconciler) instanceResolveFailed(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, cause error) (ctrl.Result, error) {
+	switch reason := instanceFailureReason(cause); reason {
+	case croniclenetv1.ReasonInstanceNotFound, croniclenetv1.ReasonNoMatchingService:
+		return r.instanceUnavailable(ctx, cronicleEvent, cause)
+	default:
+		return r.deletionBlocked(ctx, cronicleEvent, reason, cause)
+	}
+}
+
+// deletionBlocked handles the deletion of an event whose instance is misconfigured. The
+// finalizer is kept until the configuration is fixed or the cronicle.net/force-delete
+// annotation is set, UnreachableInstanceTimeout does not apply.
+func (r *CronicleEventReconciler) deletionBlocked(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, reason string, cause error) (ctrl.Result, error) {
+	if cronicleEvent.Annotations[croniclenetv1.ForceDeleteAnnotation] == "true" {
+		return r.abandonEvent(ctx, cronicleEvent, cause)
+	}
+
+	message := fmt.Sprintf("Cannot delete event %s: %v. Set the %s=true annotation to delete it without cleaning up Cronicle",
+		cronicleEvent.Status.EventId, cause, croniclenetv1.ForceDeleteAnnotation)
+	log.FromContext(ctx).Error(cause, "Cannot delete event", "eventId", cronicleEvent.Status.EventId, "reason", reason)
+	if setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, reason, message) {
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, reason, message)
+		if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if reason == croniclenetv1.ReasonNamespaceNotAllowed {
+		// Reconciled again by the watches when the instance or the namespace labels change
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, cause
+}
+
+// instanceUnavailable handles the deletion of an event whose instance is missing or unreachable.
+// The finalizer is kept until the instance comes back, the cronicle.net/force-delete
+// annotation is set or the instance stayed unavailable for UnreachableInstanceTimeout.
+func (r *CronicleEventReconciler) instanceUnavailable(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, cause error) (ctrl.Result, error) {
+	since := unavailableSince(cronicleEvent)
+	if since.IsZero() {
+		// The outage starts now, recorded as the transition time of the Deleting condition
+		since = metav1.Now().Rfc3339Copy().Time
+		meta.RemoveStatusCondition(&cronicleEvent.Status.Conditions, croniclenetv1.ConditionDeleting)
+	}
+	if r.mayAbandon(cronicleEvent, since) {
+		return r.abandonEvent(ctx, cronicleEvent, cause)
+	}
+
+	message := fmt.Sprintf("Cannot delete event %s: %v", cronicleEvent.Status.EventId, cause)
+	requeueAfter := time.Minute
+	if r.UnreachableInstanceTimeout > 0 {
+		deadline := since.Add(r.UnreachableInstanceTimeout)
+		message += fmt.Sprintf(". The resource is deleted without cleaning up Cronicle at %s", deadline.UTC().Format(time.RFC3339))
+		requeueAfter = min(requeueAfter, time.Until(deadline))
+	}
+	message += fmt.Sprintf(". Set the %s=true annotation to delete it now", croniclenetv1.ForceDeleteAnnotation)
+	log.FromContext(ctx).Info("Instance of the deleted event is unavailable", "eventId", cronicleEvent.Status.EventId, "reason", cause.Error())
+	changed := meta.SetStatusCondition(&cronicleEvent.Status.Conditions, metav1.Condition{
+		Type:               croniclenetv1.ConditionDeleting,
+		Status:             metav1.ConditionTrue,
+		Reason:             croniclenetv1.ReasonInstanceUnavailable,
+		Message:            message,
+		ObservedGeneration: cronicleEvent.Generation,
+		LastTransitionTime: metav1.NewTime(since),
+	})
+	if changed {
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonInstanceUnavailable, message)
+		if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// unavailableSince returns when the instance of a deleted event became unavailable, or the zero
+// time when it is not known to be
+func unavailableSince(cronicleEvent *croniclenetv1.CronicleEvent) time.Time {
+	deleting := meta.FindStatusCondition(cronicleEvent.Status.Conditions, croniclenetv1.ConditionDeleting)
+	if deleting == nil || deleting.Reason != croniclenetv1.ReasonInstanceUnavailable {
+		return time.Time{}
+	}
+	return deleting.LastTransitionTime.Time
+}
+
+// instanceAvailable ends the outage recorded by instanceUnavailable once Cronicle answers again
+// and reports whether the status changed
+func instanceAvailable(cronicleEvent *croniclenetv1.CronicleEvent) bool {
+	if unavailableSince(cronicleEvent).IsZero() {
+		return false
+	}
+	return setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, croniclenetv1.ReasonDeleting, "Instance is available again, deleting the event")
+}
+
+// deletionFailed handles a failed Cronicle call made to delete an event, an unreachable
+// Cronicle is handled like a missing instance
+func (r *CronicleEventReconciler) deletionFailed(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, err error) (ctrl.Result, error) {
+	if cronicle_client.IsTransient(err) {
+		return r.instanceUnavailable(ctx, cronicleEvent, err)
+	}
+	return r.apiErrorResult(err)
+}
+
+// mayAbandon reports whether a deleted event whose instance has been unavailable since the given
+// time may be removed without cleaning up Cronicle
+func (r *CronicleEventReconciler) mayAbandon(cronicleEvent *croniclenetv1.CronicleEvent, since time.Time) bool {
+	if cronicleEvent.Annotations[croniclenetv1.ForceDeleteAnnotation] == "true" {
+		return true
+	}
+	return r.UnreachableInstanceTimeout > 0 && time.Since(since) >= r.UnreachableInstanceTimeout
+}
+
+// abandonEvent removes the finalizer without cleaning up Cronicle and records that the event
+// may have been left behind. The orphan sweeper finds it should the instance come back.
+func (r *CronicleEventReconciler) abandonEvent(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, cause error) (ctrl.Result, error) {
+	eventId := cronicleEvent.Status.EventId
+	log.FromContext(ctx).Info("Removing finalizer without cleaning up Cronicle", "eventId", eventId, "reason", cause.Error())
+	r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonAbandoned,
+		"Deleted without cleaning up Cronicle, event %s may have been left behind: %v", eventId, cause)
+	return r.removeFinalizer(ctx, cronicleEvent)
+}
+
+// abortedJobsRecheck is the delay before checking that aborted jobs are gone
+const abortedJobsRecheck = 5 * time.Second
+
+// deletionGracePeriodLeft returns how much of the deletion grace period of an event being deleted is left
+func deletionGracePeriodLeft(cronicleEvent *croniclenetv1.CronicleEvent) time.Duration {
+	var gracePeriod time.Duration
+	if cronicleEvent.Spec.DeletionGracePeriod != nil {
+		gracePeriod = cronicleEvent.Spec.DeletionGracePeriod.Duration
+	}
+	return time.Until(cronicleEvent.DeletionTimestamp.Add(gracePeriod))
+}
+
+// abortRunningJobs aborts the running jobs of an event whose deletion grace period is over and
+// records them in the status. Jobs already recorded are not aborted again. The event is deleted once Cronicle reports the jobs as finished.
+func (r *CronicleEventReconciler) abortRunningJobs(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	eventId := cronicleEvent.Status.EventId
+
+	jobIds, err := cronicleClient.GetActiveJobs(ctx, eventId)
+	if err != nil {
+		l.Error(err, "Failed to get running jobs", "eventId", eventId)
+		return r.deletionFailed(ctx, cronicleEvent, err)
+	}
+
+	var aborted []string
+	for _, jobId := range jobIds {
+		if slices.Contains(cronicleEvent.Status.AbortedJobIds, jobId) {
+			// Aborted by an earlier pass, Cronicle has not finished it yet
+			continue
+		}
+		err := cronicleClient.AbortJob(ctx, jobId)
+		if cronicle_client.IsNotFound(err) {
+			// The job finished in the meantime
+			continue
+		}
+		if err != nil {
+			l.Error(err, "Failed to abort job", "eventId", eventId, "jobId", jobId)
+			cronicleEvent.Status.AbortedJobIds = append(cronicleEvent.Status.AbortedJobIds, aborted...)
+			if cronicle_client.IsTransient(err) {
+				return r.instanceUnavailable(ctx, cronicleEvent, err)
+			}
+			reason := apiFailureReason(err, croniclenetv1.ReasonAbortFailed)
+			setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, reason, fmt.Sprintf("Failed to abort job %s: %v", jobId, err))
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to abort job %s: %v", jobId, err)
+			if statusErr := r.Status().Update(ctx, cronicleEvent); statusErr != nil {
+				l.Error(statusErr, "Failed to update status")
+			}
+			return r.apiErrorResult(err)
+		}
+		aborted = append(aborted, jobId)
+	}
+
+	if len(aborted) > 0 {
+		message := "Aborted jobs still running after the deletion grace period: " + strings.Join(aborted, ", ")
+		l.Info("Aborted running jobs", "eventId", eventId, "jobIds", aborted)
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonJobsAborted, message)
+		setCondition(cronicleEvent, croniclenetv1.ConditionDeleting, metav1.ConditionTrue, croniclenetv1.ReasonJobsAborted, message)
+		cronicleEvent.Status.AbortedJobIds = append(cronicleEvent.Status.AbortedJobIds, aborted...)
+		if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: abortedJobsRecheck}, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// reconcileDrift compares the live event in Cronicle with the resolved spec and acts on the drift policy.
+// It reports whether the status of the event was modified.
+func (r *CronicleEventReconciler) reconcileDrift(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent, spec *croniclenetv1.CronicleEventSpec, params map[string]interface{}) (bool, error) {
+	l := log.FromContext(ctx)
+	eventId := cronicleEvent.Status.EventId
+	policy := cronicleEvent.Spec.DriftPolicy
+
+	if policy == croniclenetv1.DriftPolicyIgnore {
+		changed := meta.RemoveStatusCondition(&cronicleEvent.Status.Conditions, croniclenetv1.ConditionDrifted)
+		if setSynced(cronicleEvent, croniclenetv1.ReasonInSync, "Event is in sync with Cronicle") {
+			changed = true
+		}
+		return changed, nil
+	}
+
+	live, err := cronicleClient.GetEvent(ctx, eventId)
+	if cronicle_client.IsNotFound(err) {
+		if policy == croniclenetv1.DriftPolicyReport {
+			l.Info("Event is missing in Cronicle", "eventId", eventId)
+			message := fmt.Sprintf("Event %s no longer exists in Cronicle", eventId)
+			changed := setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionTrue, croniclenetv1.ReasonEventMissing, message)
+			if changed {
+				r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonEventMissing, message)
+			}
+			if setCondition(cronicleEvent, croniclenetv1.ConditionReady, metav1.ConditionFalse, croniclenetv1.ReasonEventMissing, message) {
+				changed = true
+			}
+			return changed, nil
+		}
+
+		l.Info("Event is missing in Cronicle, recreating it", "eventId", eventId)
+		newEventId, _, err := r.createOrAdoptEvent(ctx, cronicleClient, cronicleEvent, spec, params)
+		if err != nil {
+			l.Error(err, "Failed to recreate event")
+			reason := apiFailureReason(err, croniclenetv1.ReasonCreateFailed)
+			setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, reason, err.Error())
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to recreate event %s in Cronicle: %v", eventId, err)
+			return true, err
+		}
+		cronicleEvent.Status.EventId = newEventId
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonEventRecreated, "Event %s was missing in Cronicle and has been recreated as %s", eventId, newEventId)
+		setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionFalse, croniclenetv1.ReasonEventRecreated,
+			fmt.Sprintf("Event %s was missing in Cronicle and has been recreated as %s", eventId, newEventId))
+		setSynced(cronicleEvent, croniclenetv1.ReasonCreated, "Event created in Cronicle")
+		return true, nil
+	}
+	if err != nil {
+		l.Error(err, "Failed to get event", "eventId", eventId)
+		return setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionUnknown, apiFailureReason(err, croniclenetv1.ReasonDriftCheckFailed), err.Error()), err
+	}
+
+	desired := updateEventRequest(eventId, spec, params)
+	drifted, err := eventDrift(desired, live)
+	if err != nil {
+		return false, err
+	}
+
+	changed := setSynced(cronicleEvent, croniclenetv1.ReasonInSync, "Event is in sync with Cronicle")
+	if len(drifted) == 0 {
+		if setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionFalse, croniclenetv1.ReasonNoDrift, "Event in Cronicle matches the spec") {
+			changed = true
+		}
+		return changed, nil
+	}
+
+	message := "Fields changed in Cronicle: " + strings.Join(drifted, ", ")
+	if policy == croniclenetv1.DriftPolicyReport {
+		l.Info("Event drifted", "eventId", eventId, "fields", drifted)
+		if setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionTrue, croniclenetv1.ReasonDriftDetected, message) {
+			r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonDriftDetected, message)
+			changed = true
+		}
+		return changed, nil
+	}
+
+	if err := cronicleClient.UpdateEvent(ctx, desired); err != nil {
+		l.Error(err, "Failed to correct event drift", "eventId", eventId)
+		reason := apiFailureReason(err, croniclenetv1.ReasonUpdateFailed)
+		setNotReady(cronicleEvent, croniclenetv1.ConditionSynced, reason, err.Error())
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, reason, "Failed to correct drift of event %s: %v", eventId, err)
+		return true, err
+	}
+	l.Info("Event drift corrected", "eventId", eventId, "fields", drifted)
+	r.Recorder.Event(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonDriftCorrected, message)
+	setCondition(cronicleEvent, croniclenetv1.ConditionDrifted, metav1.ConditionFalse, croniclenetv1.ReasonDriftCorrected, message)
+	return true, nil
+}
+
+// eventDrift returns the sorted names of the fields of the live event that differ from the desired payload
+func eventDrift(desired cronicle_client.UpdateEventRequest, live *cronicle_client.Event) ([]string, error) {
+	want, err := fieldMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	got, err := fieldMap(live)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for field, value := range want {
+		if !reflect.DeepEqual(value, got[field]) {
+			drifted = append(drifted, field)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// fieldMap flattens a Cronicle payload into its JSON fields so that differently typed payloads can be compared
+func fieldMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
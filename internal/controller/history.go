@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// recentJobsLimit bounds the number of jobs kept in status.recentJobs
+const recentJobsLimit = 10
+
+// reconcileHistory copies the latest finished jobs of the event from Cronicle into the status.
+// It reports whether the status was modified.
+func (r *CronicleEventReconciler) reconcileHistory(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent) (bool, error) {
+	jobs, err := cronicleClient.GetEventHistory(ctx, cronicleEvent.Status.EventId, recentJobsLimit)
+	if err != nil {
+		return false, err
+	}
+	if len(jobs) == 0 {
+		return false, nil
+	}
+
+	status := &cronicleEvent.Status
+	recentJobs := make([]croniclenetv1.JobSummary, 0, len(jobs))
+	for _, job := range jobs {
+		recentJobs = append(recentJobs, jobSummary(&job))
+	}
+
+	lastRun := recentJobs[0]
+	lastSuccessfulRun := status.LastSuccessfulRun
+	for _, job := range recentJobs {
+		if job.Result == croniclenetv1.JobResultSucceeded {
+			// Older successes may have dropped out of the history window, keep them unless newer
+			if lastSuccessfulRun == nil || job.StartTime.After(lastSuccessfulRun.Time) {
+				lastSuccessfulRun = job.StartTime.DeepCopy()
+			}
+			break
+		}
+	}
+
+	for _, job := range recentJobs {
+		if !newJob(status, &job) {
+			continue
+		}
+		eventJobsTotal.WithLabelValues(cronicleEvent.Namespace, cronicleEvent.Name, job.Result).Inc()
+		if job.Result == croniclenetv1.JobResultFailed {
+			r.Recorder.Eventf(cronicleEvent, corev1.EventTypeWarning, croniclenetv1.ReasonJobFailed, "Job %s failed with code %s: %s", job.JobId, job.Code, job.Description)
+		}
+	}
+
+	old := status.DeepCopy()
+	status.LastRun = lastRun.StartTime.DeepCopy()
+	status.LastSuccessfulRun = lastSuccessfulRun
+	status.LastJobId = lastRun.JobId
+	status.LastResult = lastRun.Result
+	status.LastDuration = lastRun.Duration.DeepCopy()
+	status.RecentJobs = recentJobs
+	return !equality.Semantic.DeepEqual(old, status), nil
+}
+
+// jobSummary converts a job of the Cronicle history
+func jobSummary(job *cronicle_client.JobStatus) croniclenetv1.JobSummary {
+	summary := croniclenetv1.JobSummary{
+		JobId:       job.Id,
+		StartTime:   *unixTime(job.TimeStart),
+		Duration:    metav1.Duration{Duration: time.Duration(job.Elapsed * float64(time.Second)).Round(time.Millisecond)},
+		Result:      croniclenetv1.JobResultFailed,
+		Code:        string(job.Code),
+		Description: job.Description,
+	}
+	if job.Succeeded() {
+		summary.Result = croniclenetv1.JobResultSucceeded
+		summary.Code = "0"
+	}
+	return summary
+}
+
+// newJob reports whether a job of the history was not yet recorded in the status
+func newJob(status *croniclenetv1.CronicleEventStatus, job *croniclenetv1.JobSummary) bool {
+	if status.LastRun != nil && job.StartTime.Before(status.LastRun) {
+		return false
+	}
+	for _, recorded := range status.RecentJobs {
+		if recorded.JobId == job.JobId {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// Results of a Cronicle API call
+const (
+	callResultSuccess      = "success"
+	callResultNotFound     = "not_found"
+	callResultUnauthorized = "unauthorized"
+	callResultTransient    = "transient"
+	callResultError        = "error"
+)
+
+var (
+	cronicleRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cronicle_operator_cronicle_requests_total",
+		Help: "Number of Cronicle API calls by endpoint, instance and result",
+	}, []string{"endpoint", "instance", "result"})
+
+	cronicleRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cronicle_operator_cronicle_request_duration_seconds",
+		Help:    "Duration of Cronicle API calls, retries included, by endpoint, instance and result",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"endpoint", "instance", "result"})
+
+	// eventJobsTotal counts the finished jobs of events as they appear in the synced history
+	eventJobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cronicle_operator_event_jobs_total",
+		Help: "Number of finished jobs of a CronicleEvent by result",
+	}, []string{"namespace", "name", "result"})
+
+	// missedRunsTotal counts the scheduled runs of events that did not succeed within their grace period
+	missedRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cronicle_operator_missed_runs_total",
+		Help: "Number of scheduled runs of a CronicleEvent that did not succeed within the grace period",
+	}, []string{"namespace", "name"})
+
+	orphanedEvents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cronicle_operator_orphaned_events",
+		Help: "Number of events in Cronicle owned by a CronicleEvent that no longer exists, as of the last sweep",
+	}, []string{"instance"})
+
+	eventsDesc = prometheus.NewDesc(
+		"cronicle_operator_events",
+		"Number of CronicleEvents by instance, Ready condition status and enabled state",
+		[]string{"instance", "status", "enabled"}, nil)
+
+	// eventsCollector reports nothing until SetupWithManager hands it the cache of the manager
+	eventsCollector = &eventCollector{}
+)
+
+func init() {
+	metrics.Registry.MustRegister(cronicleRequestsTotal, cronicleRequestDuration, eventJobsTotal, missedRunsTotal, orphanedEvents, eventsCollector)
+}
+
+// forgetEventMetrics drops the series of a deleted event
+func forgetEventMetrics(cronicleEvent *croniclenetv1.CronicleEvent) {
+	labels := prometheus.Labels{"namespace": cronicleEvent.Namespace, "name": cronicleEvent.Name}
+	eventJobsTotal.DeletePartialMatch(labels)
+	missedRunsTotal.DeletePartialMatch(labels)
+}
+
+// instanceLabel names the instance of an event in metrics: the name of a ClusterCronicleInstance,
+// namespace/name of a CronicleInstance, or "selector" for the deprecated instance selector
+func instanceLabel(cronicleEvent *croniclenetv1.CronicleEvent) string {
+	switch {
+	case cronicleEvent.Spec.InstanceRef == nil:
+		return "selector"
+	case cronicleEvent.Spec.InstanceRef.Kind == croniclenetv1.ClusterCronicleInstanceKind:
+		return cronicleEvent.Spec.InstanceRef.Name
+	default:
+		return cronicleEvent.Namespace + "/" + cronicleEvent.Spec.InstanceRef.Name
+	}
+}
+
+// callResult classifies the error of a Cronicle API call
+func callResult(err error) string {
+	switch {
+	case err == nil:
+		return callResultSuccess
+	case cronicle_client.IsNotFound(err):
+		return callResultNotFound
+	case cronicle_client.IsUnauthorized(err):
+		return callResultUnauthorized
+	case cronicle_client.IsTransient(err):
+		return callResultTransient
+	default:
+		return callResultError
+	}
+}
+
+// eventCollector reports the number of CronicleEvents from the cache at every scrape
+type eventCollector struct {
+	mu     sync.RWMutex
+	reader client.Reader
+}
+
+// setReader sets the client the CronicleEvents are listed with, the last manager set up wins
+func (c *eventCollector) setReader(reader client.Reader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reader = reader
+}
+
+func (c *eventCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- eventsDesc
+}
+
+func (c *eventCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	reader := c.reader
+	c.mu.RUnlock()
+	if reader == nil {
+		return
+	}
+
+	var events croniclenetv1.CronicleEventList
+	if err := reader.List(context.Background(), &events); err != nil {
+		ch <- prometheus.NewInvalidMetric(eventsDesc, err)
+		return
+	}
+
+	type key struct{ instance, status, enabled string }
+	counts := map[key]int{}
+	for i := range events.Items {
+		cronicleEvent := &events.Items[i]
+		status := "Unknown"
+		if ready := meta.FindStatusCondition(cronicleEvent.Status.Conditions, croniclenetv1.ConditionReady); ready != nil {
+			status = string(ready.Status)
+		}
+		counts[key{instanceLabel(cronicleEvent), status, strconv.FormatBool(cronicleEvent.Spec.Enabled == 1)}]++
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(eventsDesc, prometheus.GaugeValue, float64(count), k.instance, k.status, k.enabled)
+	}
+}
+
+// instrumentedAPI records the duration and result of every call made through api
+type instrumentedAPI struct {
+	api      cronicle_client.CronicleAPI
+	instance string
+}
+
+var _ cronicle_client.CronicleAPI = instrumentedAPI{}
+
+func (c instrumentedAPI) observe(endpoint string, start time.Time, err error) {
+	result := callResult(err)
+	cronicleRequestsTotal.WithLabelValues(endpoint, c.instance, result).Inc()
+	cronicleRequestDuration.WithLabelValues(endpoint, c.instance, result).Observe(time.Since(start).Seconds())
+}
+
+func (c instrumentedAPI) CreateEvent(ctx context.Context, request cronicle_client.CreateEventRequest) (string, error) {
+	start := time.Now()
+	eventID, err := c.api.CreateEvent(ctx, request)
+	c.observe(cronicle_client.CreateEventEndpoint, start, err)
+	return eventID, err
+}
+
+func (c instrumentedAPI) UpdateEvent(ctx context.Context, request cronicle_client.UpdateEventRequest) error {
+	start := time.Now()
+	err := c.api.UpdateEvent(ctx, request)
+	c.observe(cronicle_client.UpdateEventEndpoint, start, err)
+	return err
+}
+
+func (c instrumentedAPI) DisableEvent(ctx context.Context, eventID string) error {
+	start := time.Now()
+	err := c.api.DisableEvent(ctx, eventID)
+	c.observe(cronicle_client.UpdateEventEndpoint, start, err)
+	return err
+}
+
+func (c instrumentedAPI) ReleaseEvent(ctx context.Context, eventID string) error {
+	start := time.Now()
+	err := c.api.ReleaseEvent(ctx, eventID)
+	c.observe(cronicle_client.UpdateEventEndpoint, start, err)
+	return err
+}
+
+func (c instrumentedAPI) DeleteEvent(ctx context.Context, eventID string) error {
+	start := time.Now()
+	err := c.api.DeleteEvent(ctx, eventID)
+	c.observe(cronicle_client.DeleteEventEndpoint, start, err)
+	return err
+}
+
+func (c instrumentedAPI) GetEvent(ctx context.Context, eventID string) (*cronicle_client.Event, error) {
+	start := time.Now()
+	event, err := c.api.GetEvent(ctx, eventID)
+	c.observe(cronicle_client.GetEventEndpoint, start, err)
+	return event, err
+}
+
+func (c instrumentedAPI) CheckRunningJobs(ctx context.Context, eventID string) (bool, error) {
+	start := time.Now()
+	running, err := c.api.CheckRunningJobs(ctx, eventID)
+	c.observe(cronicle_client.GetActiveJobsEndpoint, start, err)
+	return running, err
+}
+
+func (c instrumentedAPI) GetActiveJobs(ctx context.Context, eventID string) ([]string, error) {
+	start := time.Now()
+	jobIDs, err := c.api.GetActiveJobs(ctx, eventID)
+	c.observe(cronicle_client.GetActiveJobsEndpoint, start, err)
+	return jobIDs, err
+}
+
+func (c instrumentedAPI) AbortJob(ctx context.Context, jobID string) error {
+	start := time.Now()
+	err := c.api.AbortJob(ctx, jobID)
+	c.observe(cronicle_client.AbortJobEndpoint, start, err)
+	return err
+}
+
+func (c instrumentedAPI) RunEvent(ctx context.Context, request cronicle_client.RunEventRequest) ([]string, error) {
+	start := time.Now()
+	jobIDs, err := c.api.RunEvent(ctx, request)
+	c.observe(cronicle_client.RunEventEndpoint, start, err)
+	return jobIDs, err
+}
+
+func (c instrumentedAPI) GetJobStatus(ctx context.Context, jobID string) (*cronicle_client.JobStatus, error) {
+	start := time.Now()
+	job, err := c.api.GetJobStatus(ctx, jobID)
+	c.observe(cronicle_client.GetJobStatusEndpoint, start, err)
+	return job, err
+}
+
+func (c instrumentedAPI) GetJobLog(ctx context.Context, jobID string, tailBytes int) (string, error) {
+	start := time.Now()
+	jobLog, err := c.api.GetJobLog(ctx, jobID, tailBytes)
+	c.observe(cronicle_client.GetJobLogEndpoint, start, err)
+	return jobLog, err
+}
+
+func (c instrumentedAPI) GetEventHistory(ctx context.Context, eventID string, limit int) ([]cronicle_client.JobStatus, error) {
+	start := time.Now()
+	jobs, err := c.api.GetEventHistory(ctx, eventID, limit)
+	c.observe(cronicle_client.GetEventHistoryEndpoint, start, err)
+	return jobs, err
+}
+
+func (c instrumentedAPI) GetSchedule(ctx context.Context) ([]cronicle_client.Event, error) {
+	start := time.Now()
+	events, err := c.api.GetSchedule(ctx)
+	c.observe(cronicle_client.GetScheduleEndpoint, start, err)
+	return events, err
+}
+
+func (c instrumentedAPI) GetCategories(ctx context.Context) ([]cronicle_client.Category, error) {
+	start := time.Now()
+	categories, err := c.api.GetCategories(ctx)
+	c.observe(cronicle_client.GetCategoriesEndpoint, start, err)
+	return categories, err
+}
+
+func (c instrumentedAPI) GetServerGroups(ctx context.Context) ([]cronicle_client.ServerGroup, error) {
+	start := time.Now()
+	groups, err := c.api.GetServerGroups(ctx)
+	c.observe(cronicle_client.GetServerGroupsEndpoint, start, err)
+	return groups, err
+}
+
+func (c instrumentedAPI) GetPlugins(ctx context.Context) ([]cronicle_client.Plugin, error) {
+	start := time.Now()
+	plugins, err := c.api.GetPlugins(ctx)
+	c.observe(cronicle_client.GetPluginsEndpoint, start, err)
+	return plugins, err
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/schedule"
+)
+
+// reconcileMissedSchedule checks that the latest run scheduled more than the grace period ago
+// has succeeded. It relies on the job history in the status and reports whether the status
+// was modified.
+func (r *CronicleEventReconciler) reconcileMissedSchedule(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) bool {
+	status := &cronicleEvent.Status
+	gracePeriod := cronicleEvent.Spec.MissedRunGracePeriod
+	if gracePeriod == nil || cronicleEvent.Spec.Enabled != 1 {
+		return meta.RemoveStatusCondition(&status.Conditions, croniclenetv1.ConditionMissedSchedule)
+	}
+
+	changed := false
+	now := time.Now()
+	if status.ScheduledSince == nil {
+		// Events created before missed run detection existed are checked from now on
+		scheduledSince := metav1.NewTime(now)
+		status.ScheduledSince = &scheduledSince
+		changed = true
+	}
+	loc, err := time.LoadLocation(cronicleEvent.Spec.Timezone)
+	if err != nil {
+		return changed
+	}
+
+	due, ok := schedule.Last(eventTiming(&cronicleEvent.Spec), loc, status.ScheduledSince.Time, now.Add(-gracePeriod.Duration))
+	if !ok || (status.LastSuccessfulRun != nil && !status.LastSuccessfulRun.Time.Before(due)) {
+		if setCondition(cronicleEvent, croniclenetv1.ConditionMissedSchedule, metav1.ConditionFalse, croniclenetv1.ReasonOnSchedule, "Scheduled runs succeeded within the grace period") {
+			changed = true
+		}
+		return changed
+	}
+
+	scheduled := due.Format(time.RFC3339)
+	reason := croniclenetv1.ReasonRunNotStarted
+	message := fmt.Sprintf("No job started for the run scheduled at %s", scheduled)
+	if status.LastRun != nil && !status.LastRun.Time.Before(due) {
+		reason = croniclenetv1.ReasonRunNotSucceeded
+		message = fmt.Sprintf("No job succeeded for the run scheduled at %s", scheduled)
+	}
+	if status.LastMissedRun == nil || status.LastMissedRun.Time.Before(due) {
+		log.FromContext(ctx).Info("Scheduled run missed", "eventId", status.EventId, "scheduled", scheduled, "reason", reason)
+		missedRunsTotal.WithLabelValues(cronicleEvent.Namespace, cronicleEvent.Name).Inc()
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, reason, message)
+		lastMissedRun := metav1.NewTime(due)
+		status.LastMissedRun = &lastMissedRun
+		changed = true
+	}
+	if setCondition(cronicleEvent, croniclenetv1.ConditionMissedSchedule, metav1.ConditionTrue, reason, message) {
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// defaultPlugin runs the jobs of events that set neither plugin nor pluginName
+const defaultPlugin = "shellplug"
+
+// nameError is returned by resolveNames when a name matches no object, or several, in Cronicle
+type nameError struct {
+	err error
+}
+
+func (e *nameError) Error() string {
+	return e.err.Error()
+}
+
+// nameCacheTTL is how long the categories, server groups and plugins of an instance are reused
+const nameCacheTTL = time.Minute
+
+// nameCache keeps the lists names are resolved against for each instance, so that the events
+// of an instance share one lookup instead of listing them on every reconcile
+type nameCache struct {
+	mu    sync.Mutex
+	lists map[string]cachedList
+}
+
+type cachedList struct {
+	items   interface{}
+	fetched time.Time
+}
+
+// get returns the list stored under key unless it is older than nameCacheTTL
+func (c *nameCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list, ok := c.lists[key]
+	if !ok || time.Since(list.fetched) >= nameCacheTTL {
+		return nil, false
+	}
+	return list.items, true
+}
+
+func (c *nameCache) put(key string, items interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lists == nil {
+		c.lists = map[string]cachedList{}
+	}
+	c.lists[key] = cachedList{items: items, fetched: time.Now()}
+}
+
+// resolveNames returns a copy of the spec with the category, target and plugin names replaced
+// by the IDs they have in the Cronicle instance at baseUrl. The lists are reused for
+// nameCacheTTL, so an object recreated in Cronicle under the same title is picked up once
+// they expire.
+func resolveNames(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, names *nameCache, baseUrl string, spec *croniclenetv1.CronicleEventSpec) (*croniclenetv1.CronicleEventSpec, error) {
+	resolved := spec.DeepCopy()
+	var err error
+
+	if spec.CategoryName != "" {
+		resolved.Category, err = lookupID(ctx, names, baseUrl, "category", spec.CategoryName, cronicleClient.GetCategories, func(c cronicle_client.Category) (string, string) {
+			return c.Id, c.Title
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case spec.TargetHostname != "":
+		resolved.Target = spec.TargetHostname
+	case spec.TargetGroupName != "":
+		resolved.Target, err = lookupID(ctx, names, baseUrl, "server group", spec.TargetGroupName, cronicleClient.GetServerGroups, func(g cronicle_client.ServerGroup) (string, string) {
+			return g.Id, g.Title
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case spec.PluginName != "":
+		resolved.Plugin, err = lookupID(ctx, names, baseUrl, "plugin", spec.PluginName, cronicleClient.GetPlugins, func(p cronicle_client.Plugin) (string, string) {
+			return p.Id, p.Title
+		})
+		if err != nil {
+			return nil, err
+		}
+	case spec.Plugin == "":
+		resolved.Plugin = defaultPlugin
+	}
+	return resolved, nil
+}
+
+// lookupID returns the ID of the item titled name in the list of the instance, using the cached
+// copy when it has one. A name not found in the cached copy is looked up again in a fresh
+// list, so that objects just created in Cronicle are found right away.
+func lookupID[T any](ctx context.Context, names *nameCache, baseUrl, kind, name string, list func(context.Context) ([]T, error), idTitle func(T) (string, string)) (string, error) {
+	key := baseUrl + " " + kind
+	if items, ok := names.get(key); ok {
+		if id, err := findID(items.([]T), kind, name, idTitle); err == nil {
+			return id, nil
+		}
+	}
+	items, err := list(ctx)
+	if err != nil {
+		return "", err
+	}
+	names.put(key, items)
+	return findID(items, kind, name, idTitle)
+}
+
+// findID returns the ID of the only item titled name. Titles are compared case-insensitively
+// when no item matches exactly.
+func findID[T any](items []T, kind, name string, idTitle func(T) (string, string)) (string, error) {
+	var exact, folded []string
+	for _, item := range items {
+		id, title := idTitle(item)
+		switch {
+		case title == name:
+			exact = append(exact, id)
+		case strings.EqualFold(title, name):
+			folded = append(folded, id)
+		}
+	}
+	ids := exact
+	if len(ids) == 0 {
+		ids = folded
+	}
+	switch len(ids) {
+	case 0:
+		return "", &nameError{err: fmt.Errorf("no %s named %q in Cronicle", kind, name)}
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &nameError{err: fmt.Errorf("%s name %q is ambiguous, it matches %s", kind, name, strings.Join(ids, ", "))}
+	}
+}
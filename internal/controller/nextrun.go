@@ -0,0 +1,51 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/schedule"
+)
+
+// reconcileNextRun records when the event runs next and describes its schedule.
+// It reports whether the status was modified.
+func (r *CronicleEventReconciler) reconcileNextRun(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) bool {
+	timing := eventTiming(&cronicleEvent.Spec)
+	var nextRunTime *metav1.Time
+	if cronicleEvent.Spec.Enabled == 1 {
+		loc, err := time.LoadLocation(cronicleEvent.Spec.Timezone)
+		if err != nil {
+			log.FromContext(ctx).Info("Cannot compute the next run", "timezone", cronicleEvent.Spec.Timezone, "err", err.Error())
+		} else if next := schedule.Next(timing, loc, time.Now(), 1); len(next) > 0 {
+			t := metav1.NewTime(next[0])
+			nextRunTime = &t
+		}
+	}
+
+	status := &cronicleEvent.Status
+	old := status.DeepCopy()
+	status.NextRunTime = nextRunTime
+	status.ScheduleDescription = schedule.Describe(timing)
+	return !equality.Semantic.DeepEqual(old, status)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// What the OrphanSweeper does with the orphaned events it finds
+const (
+	OrphanPolicyReport  = "Report"
+	OrphanPolicyDisable = "Disable"
+	OrphanPolicyDelete  = "Delete"
+)
+
+// Kubernetes event reasons of the OrphanSweeper
+const (
+	reasonOrphanFound    = "OrphanedEvent"
+	reasonOrphanDisabled = "OrphanDisabled"
+	reasonOrphanDeleted  = "OrphanDeleted"
+)
+
+// OrphanSweeper periodically looks for events in Cronicle that carry the ownership mark of a
+// CronicleEvent that no longer exists, for example because its finalizer was removed by hand,
+// its namespace was wiped or the CRD was reinstalled. Depending on Policy the orphaned events
+// are reported, disabled or deleted.
+//
+// Events are only matched by the UID of their owner, so several clusters must not share a
+// Cronicle with the Disable or Delete policy.
+type OrphanSweeper struct {
+	client.Client
+
+	// Interval is the time between two sweeps
+	Interval time.Duration
+	Policy   string
+
+	// ClientFactory builds the Cronicle client of an instance, cronicle_client.NewCronicleAPI when nil
+	ClientFactory cronicle_client.ClientFactory
+	// Recorder receives the Kubernetes events, recorded on the instance holding the orphaned event
+	Recorder record.EventRecorder
+}
+
+var _ manager.LeaderElectionRunnable = &OrphanSweeper{}
+
+// NeedLeaderElection makes only the leader sweep
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Start sweeps every Interval until ctx is done
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("orphan-sweeper")
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Sweep(log.IntoContext(ctx, l)); err != nil {
+				l.Error(err, "Failed to sweep orphaned events")
+			}
+		}
+	}
+}
+
+// sweptInstance is an instance whose schedule is checked for orphaned events
+type sweptInstance struct {
+	object client.Object
+	label  string
+	events []cronicle_client.Event
+	api    cronicle_client.CronicleAPI
+}
+
+// Sweep checks the schedule of every instance once. Instances that cannot be reached are
+// logged and skipped.
+func (s *OrphanSweeper) Sweep(ctx context.Context) error {
+	l := log.FromContext(ctx)
+
+	instances, err := s.instances(ctx)
+	if err != nil {
+		return err
+	}
+	// The schedules are fetched before the CronicleEvents are listed, so that an event created
+	// during the sweep always finds its owner in the list
+	seen := map[string]bool{}
+	var swept []sweptInstance
+	for _, instance := range instances {
+		config, label := instance.config, instanceObjectLabel(instance.object)
+		if seen[config.BaseUrl] {
+			continue
+		}
+		seen[config.BaseUrl] = true
+
+		api, err := newCronicleClient(s.ClientFactory, config, label)
+		if err != nil {
+			l.Error(err, "Skipping instance", "instance", label)
+			continue
+		}
+		events, err := api.GetSchedule(ctx)
+		if err != nil {
+			l.Error(err, "Failed to get the schedule", "instance", label)
+			continue
+		}
+		swept = append(swept, sweptInstance{object: instance.object, label: label, events: events, api: api})
+	}
+
+	var cronicleEvents croniclenetv1.CronicleEventList
+	if err := s.List(ctx, &cronicleEvents); err != nil {
+		return err
+	}
+	owners := map[types.UID]bool{}
+	for _, cronicleEvent := range cronicleEvents.Items {
+		owners[cronicleEvent.UID] = true
+	}
+
+	orphanedEvents.Reset()
+	for _, instance := range swept {
+		orphans := 0
+		for _, event := range instance.events {
+			if event.OwnerUID == "" || owners[types.UID(event.OwnerUID)] {
+				continue
+			}
+			orphans++
+			if err := s.handleOrphan(ctx, &instance, &event); err != nil {
+				l.Error(err, "Failed to handle orphaned event", "instance", instance.label, "eventId", event.Id, "policy", s.Policy)
+			}
+		}
+		orphanedEvents.WithLabelValues(instance.label).Set(float64(orphans))
+	}
+	return nil
+}
+
+// handleOrphan applies the policy to an orphaned event
+func (s *OrphanSweeper) handleOrphan(ctx context.Context, instance *sweptInstance, event *cronicle_client.Event) error {
+	l := log.FromContext(ctx).WithValues("instance", instance.label, "eventId", event.Id, "title", event.Title, "ownerUid", event.OwnerUID)
+	description := fmt.Sprintf("Event %s (%s) belongs to a CronicleEvent that no longer exists", event.Id, event.Title)
+
+	switch s.Policy {
+	case OrphanPolicyDisable, OrphanPolicyDelete:
+		if event.Enabled != 0 {
+			if err := instance.api.DisableEvent(ctx, event.Id); err != nil && !cronicle_client.IsNotFound(err) {
+				return err
+			}
+			l.Info("Orphaned event disabled")
+			s.Recorder.Event(instance.object, corev1.EventTypeNormal, reasonOrphanDisabled, description+", disabled it")
+		}
+		if s.Policy == OrphanPolicyDisable {
+			return nil
+		}
+		// Cronicle refuses to delete events with running jobs, the next sweep tries again
+		if err := instance.api.DeleteEvent(ctx, event.Id); err != nil && !cronicle_client.IsNotFound(err) {
+			return err
+		}
+		l.Info("Orphaned event deleted")
+		s.Recorder.Event(instance.object, corev1.EventTypeNormal, reasonOrphanDeleted, description+", deleted it")
+	default:
+		l.Info("Found orphaned event")
+		s.Recorder.Event(instance.object, corev1.EventTypeWarning, reasonOrphanFound, description)
+	}
+	return nil
+}
+
+// configuredInstance is a CronicleInstance or ClusterCronicleInstance with its client configuration
+type configuredInstance struct {
+	object client.Object
+	config cronicle_client.Config
+}
+
+// instances lists the instances the operator can reach. Those whose configuration cannot be
+// resolved are logged and skipped.
+func (s *OrphanSweeper) instances(ctx context.Context) ([]configuredInstance, error) {
+	l := log.FromContext(ctx)
+	resolver := instanceResolver{s.Client}
+	var instances []configuredInstance
+
+	var namespaced croniclenetv1.CronicleInstanceList
+	if err := s.List(ctx, &namespaced); err != nil {
+		return nil, err
+	}
+	for i := range namespaced.Items {
+		instance := &namespaced.Items[i]
+		config, err := resolver.instanceConfig(ctx, instance.Namespace, &instance.Spec)
+		if err != nil {
+			l.Error(err, "Skipping instance", "instance", instanceObjectLabel(instance))
+			continue
+		}
+		instances = append(instances, configuredInstance{object: instance, config: config})
+	}
+
+	var cluster croniclenetv1.ClusterCronicleInstanceList
+	if err := s.List(ctx, &cluster); err != nil {
+		return nil, err
+	}
+	for i := range cluster.Items {
+		instance := &cluster.Items[i]
+		config, err := resolver.instanceConfig(ctx, instance.Spec.Namespace, &instance.Spec.CronicleInstanceSpec)
+		if err != nil {
+			l.Error(err, "Skipping instance", "instance", instanceObjectLabel(instance))
+			continue
+		}
+		instances = append(instances, configuredInstance{object: instance, config: config})
+	}
+	return instances, nil
+}
+
+// instanceObjectLabel names an instance in metrics the way instanceLabel does
+func instanceObjectLabel(instance client.Object) string {
+	if instance.GetNamespace() == "" {
+		return instance.GetName()
+	}
+	return instance.GetNamespace() + "/" + instance.GetName()
+}
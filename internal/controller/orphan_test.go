@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client/fake"
+)
+
+var _ = Describe("OrphanSweeper", func() {
+	const instanceName = "orphan-instance"
+	const secretName = "orphan-api-key"
+
+	ctx := context.Background()
+
+	var cronicle *fake.Server
+	var owned, orphan, unmarked string
+
+	sweep := func(policy string) {
+		sweeper := &OrphanSweeper{Client: k8sClient, Policy: policy, Recorder: record.NewFakeRecorder(100)}
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		cronicle = fake.NewServer()
+		DeferCleanup(cronicle.Close)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+			StringData: map[string]string{"apiKey": fake.APIKey},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, secret)
+
+		instance := &croniclenetv1.CronicleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			Spec: croniclenetv1.CronicleInstanceSpec{
+				URL: cronicle.URL,
+				APIKeySecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  "apiKey",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, instance)
+
+		cronicleEvent := &croniclenetv1.CronicleEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: "orphan-owner", Namespace: "default"},
+			Spec: croniclenetv1.CronicleEventSpec{
+				Category:    "general",
+				Enabled:     1,
+				Plugin:      "shellplug",
+				Target:      "allgrp",
+				Title:       "Owned event",
+				InstanceRef: &croniclenetv1.InstanceReference{Name: instanceName},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cronicleEvent)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, cronicleEvent)
+
+		By("creating an owned, an orphaned and an unmarked event in Cronicle")
+		createEvent := func(ownerUID string) string {
+			request := createEventRequest(&cronicleEvent.Spec, cronicleEvent.Spec.Params.Values())
+			request.OwnerUID = ownerUID
+			eventId, err := cronicle_client.NewClient(cronicle.Config()).CreateEvent(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			return eventId
+		}
+		owned = createEvent(string(cronicleEvent.UID))
+		orphan = createEvent("deleted-object-uid")
+		unmarked = createEvent("")
+	})
+
+	It("should only report orphaned events with the Report policy", func() {
+		sweep(OrphanPolicyReport)
+		Expect(cronicle.EventIDs()).To(ConsistOf(owned, orphan, unmarked))
+		Expect(cronicle.Event(orphan)).To(HaveKeyWithValue("enabled", BeEquivalentTo(1)))
+	})
+
+	It("should disable orphaned events with the Disable policy", func() {
+		sweep(OrphanPolicyDisable)
+		Expect(cronicle.Event(orphan)).To(HaveKeyWithValue("enabled", BeEquivalentTo(0)))
+		Expect(cronicle.Event(owned)).To(HaveKeyWithValue("enabled", BeEquivalentTo(1)))
+		Expect(cronicle.Event(unmarked)).To(HaveKeyWithValue("enabled", BeEquivalentTo(1)))
+	})
+
+	It("should delete orphaned events with the Delete policy", func() {
+		sweep(OrphanPolicyDelete)
+		Expect(cronicle.EventIDs()).To(ConsistOf(owned, unmarked))
+	})
+})
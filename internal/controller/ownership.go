@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// createOrAdoptEvent creates the Cronicle event of cronicleEvent from its resolved spec, marked with its UID. When the
+// schedule already holds an event with that mark, created by an earlier reconcile whose status
+// update was lost, that event is updated to the spec and adopted instead of creating a duplicate.
+// An event named by the cronicle.net/adopt-event-id annotation is adopted the same way.
+// It returns the ID of the event and whether it was adopted.
+func (r *CronicleEventReconciler) createOrAdoptEvent(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent, spec *croniclenetv1.CronicleEventSpec, params map[string]interface{}) (string, bool, error) {
+	owned, err := ownedEvent(ctx, cronicleClient, cronicleEvent)
+	if err != nil {
+		return "", false, err
+	}
+	if owned != nil {
+		if err := cronicleClient.UpdateEvent(ctx, updateEventRequest(owned.Id, spec, params)); err != nil {
+			return "", false, err
+		}
+		log.FromContext(ctx).Info("Adopted existing event", "eventId", owned.Id)
+		r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonAdopted, "Adopted event %s already created in Cronicle for this object", owned.Id)
+		return owned.Id, true, nil
+	}
+	if eventId := cronicleEvent.Annotations[croniclenetv1.AdoptEventAnnotation]; eventId != "" {
+		adopted, err := r.adoptNamedEvent(ctx, cronicleClient, cronicleEvent, eventId, spec, params)
+		if err != nil || adopted {
+			return eventId, adopted, err
+		}
+	}
+
+	request := createEventRequest(spec, params)
+	request.OwnerUID = string(cronicleEvent.UID)
+	eventId, err := cronicleClient.CreateEvent(ctx, request)
+	return eventId, false, err
+}
+
+// ownedEvent looks up the event of the schedule marked with the UID of cronicleEvent. If
+// several are, the oldest one is returned.
+func ownedEvent(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent) (*cronicle_client.Event, error) {
+	events, err := cronicleClient.GetSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned *cronicle_client.Event
+	for i := range events {
+		event := &events[i]
+		if event.OwnerUID == "" || event.OwnerUID != string(cronicleEvent.UID) {
+			continue
+		}
+		if owned != nil {
+			log.FromContext(ctx).Info("Several events in Cronicle belong to this object", "eventIds", []string{owned.Id, event.Id})
+			if event.Created >= owned.Created {
+				continue
+			}
+		}
+		owned = event
+	}
+	return owned, nil
+}
+
+// adoptNamedEvent updates the event named by the cronicle.net/adopt-event-id annotation to the spec
+// and marks it with the UID of cronicleEvent. Events marked by another CronicleEvent that still
+// exists are refused. It reports false when the event is missing in Cronicle.
+func (r *CronicleEventReconciler) adoptNamedEvent(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent, eventId string, spec *croniclenetv1.CronicleEventSpec, params map[string]interface{}) (bool, error) {
+	l := log.FromContext(ctx)
+	event, err := cronicleClient.GetEvent(ctx, eventId)
+	if cronicle_client.IsNotFound(err) {
+		l.Info("Event to adopt is missing in Cronicle, creating a new one", "eventId", eventId)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if event.OwnerUID != "" && event.OwnerUID != string(cronicleEvent.UID) {
+		owned, err := r.ownerExists(ctx, types.UID(event.OwnerUID))
+		if err != nil {
+			return false, err
+		}
+		if owned {
+			return false, fmt.Errorf("event %s named by the %s annotation belongs to another CronicleEvent", eventId, croniclenetv1.AdoptEventAnnotation)
+		}
+	}
+
+	request := updateEventRequest(eventId, spec, params)
+	request.OwnerUID = string(cronicleEvent.UID)
+	if err := cronicleClient.UpdateEvent(ctx, request); err != nil {
+		return false, err
+	}
+	l.Info("Adopted existing event", "eventId", eventId)
+	r.Recorder.Eventf(cronicleEvent, corev1.EventTypeNormal, croniclenetv1.ReasonAdopted, "Adopted event %s named by the %s annotation", eventId, croniclenetv1.AdoptEventAnnotation)
+	return true, nil
+}
+
+// ownerExists reports whether a CronicleEvent with the given UID exists
+func (r *CronicleEventReconciler) ownerExists(ctx context.Context, uid types.UID) (bool, error) {
+	var cronicleEvents croniclenetv1.CronicleEventList
+	if err := r.List(ctx, &cronicleEvents); err != nil {
+		return false, err
+	}
+	for _, cronicleEvent := range cronicleEvents.Items {
+		if cronicleEvent.UID == uid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
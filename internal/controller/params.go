@@ -0,0 +1,331 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+)
+
+// paramsRefIndex indexes CronicleEvents by the ConfigMaps and Secrets their parameters are read from
+const paramsRefIndex = ".spec.params.refs"
+
+// paramsError is returned by eventParams when the parameters of an event cannot be built
+type paramsError struct {
+	reason string
+	err    error
+}
+
+func (e *paramsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *paramsError) Unwrap() error {
+	return e.err
+}
+
+// paramsRefKey returns the paramsRefIndex value of a ConfigMap or Secret
+func paramsRefKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// templateData is what the templates of the script and the parameters are rendered with
+type templateData struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	InstanceURL string
+	Values      map[string]string
+}
+
+// paramsRefs returns the paramsRefIndex values of an event
+func paramsRefs(cronicleEvent *croniclenetv1.CronicleEvent) []string {
+	var refs []string
+	keyRefs := func(configMapRef *corev1.ConfigMapKeySelector, secretRef *corev1.SecretKeySelector) {
+		switch {
+		case configMapRef != nil:
+			refs = append(refs, paramsRefKey("ConfigMap", configMapRef.Name))
+		case secretRef != nil:
+			refs = append(refs, paramsRefKey("Secret", secretRef.Name))
+		}
+	}
+	if source := cronicleEvent.Spec.Params.ScriptFrom; source != nil {
+		keyRefs(source.ConfigMapKeyRef, source.SecretKeyRef)
+	}
+	for _, source := range cronicleEvent.Spec.TemplateValuesFrom {
+		refs = append(refs, paramsRefKey("ConfigMap", source.ConfigMapRef.Name))
+	}
+	for _, envVar := range cronicleEvent.Spec.Env {
+		if envVar.ValueFrom != nil {
+			keyRefs(envVar.ValueFrom.ConfigMapKeyRef, envVar.ValueFrom.SecretKeyRef)
+		}
+	}
+	return refs
+}
+
+// eventParams returns the plugin parameters of an event as sent to Cronicle and their hash.
+// The script is read from scriptFrom when it is set, and when the event has template values the
+// script and the string parameters are rendered as templates. The environment variables are
+// added last so that secret values are never rendered.
+func (r *CronicleEventReconciler) eventParams(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent, instanceURL string) (map[string]interface{}, string, error) {
+	params := cronicleEvent.Spec.Params.Values()
+	if source := cronicleEvent.Spec.Params.ScriptFrom; source != nil {
+		script, _, err := r.readKeyRef(ctx, cronicleEvent.Namespace, source.ConfigMapKeyRef, source.SecretKeyRef, croniclenetv1.ReasonScriptNotFound)
+		if err != nil {
+			return nil, "", err
+		}
+		if script != "" {
+			params["script"] = script
+		}
+	}
+
+	if cronicleEvent.Spec.TemplateValues != nil || len(cronicleEvent.Spec.TemplateValuesFrom) > 0 {
+		values, err := r.templateValues(ctx, cronicleEvent)
+		if err != nil {
+			return nil, "", err
+		}
+		data := templateData{
+			Name:        cronicleEvent.Name,
+			Namespace:   cronicleEvent.Namespace,
+			Labels:      cronicleEvent.Labels,
+			Annotations: cronicleEvent.Annotations,
+			InstanceURL: instanceURL,
+			Values:      values,
+		}
+		if err := renderParams(params, data); err != nil {
+			return nil, "", &paramsError{reason: croniclenetv1.ReasonRenderFailed, err: err}
+		}
+	}
+
+	env, versions, err := r.eventEnv(ctx, cronicleEvent)
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := paramsHash(params, versions)
+	if err != nil {
+		return nil, "", err
+	}
+	for name, value := range env {
+		if _, ok := params[name]; ok {
+			return nil, "", &paramsError{reason: croniclenetv1.ReasonInvalidEnv, err: fmt.Errorf("env %s clashes with the plugin parameter of the same name", name)}
+		}
+		params[name] = value
+	}
+	return params, hash, nil
+}
+
+// templateValues merges the keys of the templateValuesFrom ConfigMaps with the templateValues of an event
+func (r *CronicleEventReconciler) templateValues(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) (map[string]string, error) {
+	values := map[string]string{}
+	for _, source := range cronicleEvent.Spec.TemplateValuesFrom {
+		ref := source.ConfigMapRef
+		configMap := &corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: cronicleEvent.Namespace}, configMap)
+		if apierrors.IsNotFound(err) {
+			if ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			return nil, &paramsError{reason: croniclenetv1.ReasonValuesNotFound, err: fmt.Errorf("ConfigMap %s/%s not found", cronicleEvent.Namespace, ref.Name)}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s/%s: %w", cronicleEvent.Namespace, ref.Name, err)
+		}
+		for key, value := range configMap.Data {
+			values[key] = value
+		}
+	}
+	for key, value := range cronicleEvent.Spec.TemplateValues {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// renderParams renders the string parameters as templates, including the strings nested in
+// object and array parameters
+func renderParams(params map[string]interface{}, data templateData) error {
+	for key, value := range params {
+		if raw, ok := value.(json.RawMessage); ok {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			if err := decoder.Decode(&value); err != nil {
+				return fmt.Errorf("invalid parameter %s: %w", key, err)
+			}
+		}
+		rendered, err := renderValue(key, value, data)
+		if err != nil {
+			return err
+		}
+		params[key] = rendered
+	}
+	return nil
+}
+
+// renderValue renders the strings of a decoded JSON value, name locates the value in errors
+func renderValue(name string, value interface{}, data templateData) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, err
+		}
+		return out.String(), nil
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderValue(name+"."+key, item, data)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderValue(name+"["+strconv.Itoa(i)+"]", item, data)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	}
+	return value, nil
+}
+
+// readKeyRef reads the key selected by a ConfigMap or Secret key selector, exactly one of which
+// is set. Besides the value it returns the kind, name and resource version of the object read.
+// A missing optional key reads as an empty value, reason is used for missing required ones.
+func (r *CronicleEventReconciler) readKeyRef(ctx context.Context, namespace string, configMapRef *corev1.ConfigMapKeySelector, secretRef *corev1.SecretKeySelector, reason string) (string, string, error) {
+	var kind, name, key, version string
+	var optional *bool
+	var data map[string]string
+	var binaryData map[string][]byte
+	var err error
+	switch {
+	case configMapRef != nil:
+		kind, name, key, optional = "ConfigMap", configMapRef.Name, configMapRef.Key, configMapRef.Optional
+		configMap := &corev1.ConfigMap{}
+		err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, configMap)
+		data, binaryData, version = configMap.Data, configMap.BinaryData, configMap.ResourceVersion
+	case secretRef != nil:
+		kind, name, key, optional = "Secret", secretRef.Name, secretRef.Key, secretRef.Optional
+		secret := &corev1.Secret{}
+		err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+		binaryData, version = secret.Data, secret.ResourceVersion
+	default:
+		return "", "", &paramsError{reason: reason, err: errors.New("neither configMapKeyRef nor secretKeyRef is set")}
+	}
+	isOptional := optional != nil && *optional
+	source := fmt.Sprintf("%s/%s@%s", kind, name, version)
+
+	if apierrors.IsNotFound(err) {
+		if isOptional {
+			return "", "", nil
+		}
+		return "", "", &paramsError{reason: reason, err: fmt.Errorf("%s %s/%s not found", kind, namespace, name)}
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
+	}
+	if value, ok := data[key]; ok {
+		return value, source, nil
+	}
+	if value, ok := binaryData[key]; ok {
+		return string(value), source, nil
+	}
+	if isOptional {
+		return "", source, nil
+	}
+	return "", "", &paramsError{reason: reason, err: fmt.Errorf("key %q not found in %s %s/%s", key, kind, namespace, name)}
+}
+
+// eventEnv resolves the environment variables of an event. In the returned versions the values
+// read from Secrets are replaced with the Secret and its resource version, they are hashed
+// into ParamsHash instead of the secret values.
+func (r *CronicleEventReconciler) eventEnv(ctx context.Context, cronicleEvent *croniclenetv1.CronicleEvent) (map[string]string, map[string]string, error) {
+	env := make(map[string]string, len(cronicleEvent.Spec.Env))
+	versions := make(map[string]string, len(cronicleEvent.Spec.Env))
+	for _, envVar := range cronicleEvent.Spec.Env {
+		if envVar.ValueFrom == nil {
+			env[envVar.Name], versions[envVar.Name] = envVar.Value, envVar.Value
+			continue
+		}
+		source := envVar.ValueFrom
+		value, version, err := r.readKeyRef(ctx, cronicleEvent.Namespace, source.ConfigMapKeyRef, source.SecretKeyRef, croniclenetv1.ReasonEnvNotFound)
+		if err != nil {
+			return nil, nil, fmt.Errorf("env %s: %w", envVar.Name, err)
+		}
+		env[envVar.Name] = value
+		if source.SecretKeyRef != nil {
+			versions[envVar.Name] = version
+		} else {
+			versions[envVar.Name] = value
+		}
+	}
+	return env, versions, nil
+}
+
+// paramsHash returns the hash recorded in the ParamsHash status field, of the parameters and
+// of the environment variables with secret values replaced by their versions
+func paramsHash(params map[string]interface{}, env map[string]string) (string, error) {
+	var data []byte
+	var err error
+	if len(env) == 0 {
+		data, err = json.Marshal(params)
+	} else {
+		data, err = json.Marshal(map[string]interface{}{"params": params, "env": env})
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// eventsForConfigMap maps a ConfigMap to the CronicleEvents reading their parameters from it
+func (r *CronicleEventReconciler) eventsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.eventsReferencing(ctx,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{paramsRefIndex: paramsRefKey("ConfigMap", obj.GetName())},
+	)
+}
+
+// eventsForSecret maps a Secret to the CronicleEvents reading their parameters from it
+func (r *CronicleEventReconciler) eventsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.eventsReferencing(ctx,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{paramsRefIndex: paramsRefKey("Secret", obj.GetName())},
+	)
+}
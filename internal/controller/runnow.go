@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Yasin AHLATCI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	croniclenetv1 "github.com/yasinahlattci/cronicle-operator/api/v1"
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// reconcileRunNow starts one job when the run-now annotation holds a value that was not handled yet.
+// The value is claimed in the status before the job is started, so that a failed status write
+// cannot start it twice. A request rejected by Cronicle is reported in the RunNow condition and
+// stays handled without job IDs so that it does not fire later by surprise, only transient
+// failures release the claim and are retried. It reports whether the status was modified.
+func (r *CronicleEventReconciler) reconcileRunNow(ctx context.Context, cronicleClient cronicle_client.CronicleAPI, cronicleEvent *croniclenetv1.CronicleEvent) (bool, error) {
+	l := log.FromContext(ctx)
+	runNow := cronicleEvent.Annotations[croniclenetv1.RunNowAnnotation]
+	if runNow == "" {
+		return false, nil
+	}
+	if runNow == cronicleEvent.Status.RunNow {
+		condition := meta.FindStatusCondition(cronicleEvent.Status.Conditions, croniclenetv1.ConditionRunNow)
+		if condition == nil || condition.Reason != croniclenetv1.ReasonRunPending {
+			return false, nil
+		}
+		// A previous attempt stopped between claiming the request and recording its jobs
+		setCondition(cronicleEvent, croniclenetv1.ConditionRunNow, metav1.ConditionUnknown, croniclenetv1.ReasonRunFailed,
+			fmt.Sprintf("Interrupted while starting run-now %q, the job may or may not have been started", runNow))
+		return true, nil
+	}
+
+	handled := cronicleEvent.Status.RunNow
+	cronicleEvent.Status.RunNow = runNow
+	cronicleEvent.Status.RunNowJobIds = nil
+	setCondition(cronicleEvent, croniclenetv1.ConditionRunNow, metav1.ConditionUnknown, croniclenetv1.ReasonRunPending,
+		fmt.Sprintf("Starting a job for run-now %q", runNow))
+	if err := r.Status().Update(ctx, cronicleEvent); err != nil {
+		return false, err
+	}
+
+	jobIds, err := cronicleClient.RunEvent(ctx, cronicle_client.RunEventRequest{Id: cronicleEvent.Status.EventId})
+	if cronicle_client.IsTransient(err) {
+		l.Error(err, "Failed to run event, retrying", "eventId", cronicleEvent.Status.EventId, "runNow", runNow)
+		cronicleEvent.Status.RunNow = handled
+		setCondition(cronicleEvent, croniclenetv1.ConditionRunNow, metav1.ConditionFalse, croniclenetv1.ReasonRunFailed,
+			fmt.Sprintf("Failed to reach Cronicle for run-now %q, retrying: %v", runNow, err))
+		return true, err
+	}
+	if err != nil {
+		l.Error(err, "Failed to run event", "eventId", cronicleEvent.Status.EventId, "runNow", runNow)
+		reason := apiFailureReason(err, croniclenetv1.ReasonRunFailed)
+		message := fmt.Sprintf("Cronicle refused to run event %s for run-now %q: %v", cronicleEvent.Status.EventId, runNow, err)
+		setCondition(cronicleEvent, croniclenetv1.ConditionRunNow, metav1.ConditionFalse, reason, message)
+		r.Recorder.Event(cronicleEvent, corev1.EventTypeWarning, reason, message)
+		return true, nil
+	}
+	l.Info("Event started", "eventId", cronicleEvent.Status.EventId, "runNow", runNow, "jobIds", jobIds)
+	setCondition(cronicleEvent, croniclenetv1.ConditionRunNow, metav1.ConditionTrue, croniclenetv1.ReasonRunStarted,
+		fmt.Sprintf("Started jobs %s for run-now %q", strings.Join(jobIds, ", "), runNow))
+	cronicleEvent.Status.RunNowJobIds = jobIds
+	return true, nil
+}
@@ -0,0 +1,40 @@
+package cronicle_client
+
+import (
+	"context"
+)
+
+// CronicleAPI is the set of Cronicle calls the operator relies on
+type CronicleAPI interface {
+	CreateEvent(ctx context.Context, request CreateEventRequest) (string, error)
+	UpdateEvent(ctx context.Context, request UpdateEventRequest) error
+	DisableEvent(ctx context.Context, eventID string) error
+	ReleaseEvent(ctx context.Context, eventID string) error
+	DeleteEvent(ctx context.Context, eventID string) error
+	GetEvent(ctx context.Context, eventID string) (*Event, error)
+	CheckRunningJobs(ctx context.Context, eventID string) (bool, error)
+	GetActiveJobs(ctx context.Context, eventID string) ([]string, error)
+	AbortJob(ctx context.Context, jobID string) error
+	RunEvent(ctx context.Context, request RunEventRequest) ([]string, error)
+	GetJobStatus(ctx context.Context, jobID string) (*JobStatus, error)
+	GetJobLog(ctx context.Context, jobID string, tailBytes int) (string, error)
+	GetEventHistory(ctx context.Context, eventID string, limit int) ([]JobStatus, error)
+	GetSchedule(ctx context.Context) ([]Event, error)
+	GetCategories(ctx context.Context) ([]Category, error)
+	GetServerGroups(ctx context.Context) ([]ServerGroup, error)
+	GetPlugins(ctx context.Context) ([]Plugin, error)
+}
+
+var _ CronicleAPI = &Client{}
+
+// ClientFactory builds the CronicleAPI used to talk to the Cronicle described by config
+type ClientFactory func(config Config) (CronicleAPI, error)
+
+// NewCronicleAPI is the ClientFactory returning a Client. Unlike NewClient it reports an
+// invalid configuration as an error instead of panicking.
+func NewCronicleAPI(config Config) (CronicleAPI, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return NewClient(config), nil
+}
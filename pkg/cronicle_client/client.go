@@ -1,7 +1,23 @@
 package cronicle_client
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"time"
+)
+
+const (
+	// retryBaseDelay is the backoff before the first retry, doubled on every further attempt
+	retryBaseDelay = 200 * time.Millisecond
+	// retryMaxDelay caps the backoff between two attempts
+	retryMaxDelay = 5 * time.Second
 )
 
 // Client is a struct that holds the base URL and API key
@@ -24,3 +40,91 @@ func NewClient(config Config) *Client {
 		config: config,
 	}
 }
+
+// do sends a request to a Cronicle API endpoint and decodes the JSON response into out, or
+// stores the raw body when out is a *[]byte.
+// Transient failures are retried up to Config.RetryAttempts times with exponential backoff
+// and jitter. Requests that are not idempotent are only retried when Cronicle cannot have
+// acted on them: the connection was never established or the request was rate limited.
+func (c *Client) do(ctx context.Context, method, endpoint string, body interface{}, out interface{}, idempotent bool) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.doOnce(ctx, method, endpoint, payload, out)
+		if err == nil || ctx.Err() != nil || attempt >= c.config.RetryAttempts || !retryable(err, idempotent) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+func (c *Client) doOnce(ctx context.Context, method, endpoint string, payload []byte, out interface{}) error {
+	url := fmt.Sprintf("%s%s", c.config.BaseUrl, endpoint)
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", c.config.APIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode}
+	}
+	if raw, ok := out.(*[]byte); ok {
+		// Plain text endpoints such as job logs
+		*raw, err = io.ReadAll(resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// retryable reports whether a failed attempt may be sent again
+func retryable(err error, idempotent bool) bool {
+	if apiErr, ok := asAPIError(err); ok {
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return idempotent && apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var netErr net.Error
+	return idempotent && errors.As(err, &netErr)
+}
+
+// backoff returns the delay before retry number attempt, using full jitter
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
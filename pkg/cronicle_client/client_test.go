@@ -0,0 +1,242 @@
+package cronicle_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(Config{
+		BaseUrl:       server.URL,
+		APIKey:        "test",
+		Timeout:       time.Second,
+		RetryAttempts: 2,
+	})
+}
+
+func TestUpdateEventRetriesServerErrors(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0}`))
+	})
+
+	if err := c.UpdateEvent(context.Background(), UpdateEventRequest{Id: "e1"}); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestCreateEventDoesNotRetryServerErrors(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if _, err := c.CreateEvent(context.Background(), CreateEventRequest{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestCreateEventRetriesRateLimits(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"id":"e1"}`))
+	})
+
+	id, err := c.CreateEvent(context.Background(), CreateEventRequest{})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if id != "e1" || calls != 2 {
+		t.Fatalf("expected e1 after 2 calls, got %q after %d", id, calls)
+	}
+}
+
+func TestCanceledContextStopsRetries(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.DisableEvent(ctx, "e1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 0 {
+		t.Fatalf("expected no calls, got %d", calls)
+	}
+}
+
+func TestGetEventReturnsTypedErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Api-Key") {
+		case "test":
+			_, _ = w.Write([]byte(`{"code":"event","description":"Failed to locate event: e1"}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":"api","description":"Invalid API Key: nope"}`))
+		}
+	})
+
+	_, err := c.GetEvent(context.Background(), "e1")
+	if !IsNotFound(err) || IsUnauthorized(err) || IsTransient(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	c.config.APIKey = "nope"
+	_, err = c.GetEvent(context.Background(), "e1")
+	if !IsUnauthorized(err) || IsNotFound(err) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestRunEventSendsParamsAndIsNotRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			var request RunEventRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Params["script"] != "echo override" {
+				t.Errorf("unexpected request %+v: %v", request, err)
+			}
+			_, _ = w.Write([]byte(`{"code":0,"ids":["j1","j2"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	ids, err := c.RunEvent(context.Background(), RunEventRequest{Id: "e1", Params: map[string]interface{}{"script": "echo override"}})
+	if err != nil || len(ids) != 2 || ids[0] != "j1" {
+		t.Fatalf("expected jobs j1 and j2, got %v: %v", ids, err)
+	}
+
+	if _, err := c.RunEvent(context.Background(), RunEventRequest{Id: "e1"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestGetJobLogReturnsTheEndOfTheLog(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != GetJobLogEndpoint || r.URL.Query().Get("id") != "j1" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("first line\nlast line\n"))
+	})
+
+	log, err := c.GetJobLog(context.Background(), "j1", 10)
+	if err != nil || log != "last line\n" {
+		t.Fatalf("expected the last line, got %q: %v", log, err)
+	}
+
+	if _, err := c.GetJobLog(context.Background(), "j2", 10); !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestGetEventHistoryMarksJobsComplete(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"rows":[{"id":"j2","code":1,"description":"failed"},{"id":"j1","code":0}]}`))
+	})
+
+	jobs, err := c.GetEventHistory(context.Background(), "e1", 10)
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %v: %v", jobs, err)
+	}
+	if jobs[0].Succeeded() || !jobs[1].Succeeded() {
+		t.Fatalf("expected j2 to fail and j1 to succeed, got %+v", jobs)
+	}
+}
+
+func TestGetScheduleFetchesAllPages(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]int
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request["offset"] == 0 {
+			_, _ = w.Write([]byte(`{"code":0,"rows":[{"id":"e1"},{"id":"e2","k8s_owner_uid":"uid-2"}],"list":{"length":3}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"rows":[{"id":"e3"}],"list":{"length":3}}`))
+	})
+
+	events, err := c.GetSchedule(context.Background())
+	if err != nil || len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v: %v", events, err)
+	}
+	if events[1].OwnerUID != "uid-2" || events[2].Id != "e3" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestGetActiveJobsReturnsAuthErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":"api","description":"Invalid API Key: nope"}`))
+	})
+
+	jobIDs, err := c.GetActiveJobs(context.Background(), "e1")
+	if !IsUnauthorized(err) || jobIDs != nil {
+		t.Fatalf("expected an unauthorized error, got %v: %v", jobIDs, err)
+	}
+	if running, err := c.CheckRunningJobs(context.Background(), "e1"); err == nil || running {
+		t.Fatalf("expected CheckRunningJobs to fail, got %v", running)
+	}
+}
+
+func TestGetServerGroupsFetchesAllPages(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != GetServerGroupsEndpoint {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var request map[string]int
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request["offset"] == 0 {
+			_, _ = w.Write([]byte(`{"code":0,"rows":[{"id":"allgrp","title":"All Servers","regexp":".+"}],"list":{"length":2}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"rows":[{"id":"maingrp","title":"Master Group"}],"list":{"length":2}}`))
+	})
+
+	groups, err := c.GetServerGroups(context.Background())
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("expected 2 server groups, got %v: %v", groups, err)
+	}
+	if groups[0].Regexp != ".+" || groups[1].Title != "Master Group" {
+		t.Fatalf("unexpected server groups %+v", groups)
+	}
+}
+
+func TestGetActiveJobsFiltersByEvent(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"jobs":{"j3":{"event":"e1"},"j2":{"event":"e2"},"j1":{"event":"e1"}}}`))
+	})
+
+	jobIDs, err := c.GetActiveJobs(context.Background(), "e1")
+	if err != nil || len(jobIDs) != 2 || jobIDs[0] != "j1" || jobIDs[1] != "j3" {
+		t.Fatalf("expected [j1 j3], got %v: %v", jobIDs, err)
+	}
+}
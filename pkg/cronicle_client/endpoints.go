@@ -1,28 +1,175 @@
 package cronicle_client
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 )
 
 const (
-	CreateEventEndpoint   = "/api/app/create_event/v1"
-	UpdateEventEndpoint   = "/api/app/update_event/v1"
-	DeleteEventEndpoint   = "/api/app/delete_event/v1"
-	getActiveJobsEndpoint = "/api/app/get_active_jobs/v1"
+	CreateEventEndpoint     = "/api/app/create_event/v1"
+	UpdateEventEndpoint     = "/api/app/update_event/v1"
+	DeleteEventEndpoint     = "/api/app/delete_event/v1"
+	GetEventEndpoint        = "/api/app/get_event/v1"
+	GetActiveJobsEndpoint   = "/api/app/get_active_jobs/v1"
+	RunEventEndpoint        = "/api/app/run_event/v1"
+	GetJobStatusEndpoint    = "/api/app/get_job_status/v1"
+	GetJobLogEndpoint       = "/api/app/get_job_log"
+	GetEventHistoryEndpoint = "/api/app/get_event_history/v1"
+	GetScheduleEndpoint     = "/api/app/get_schedule/v1"
+	AbortJobEndpoint        = "/api/app/abort_job/v1"
+	GetCategoriesEndpoint   = "/api/app/get_categories/v1"
+	GetServerGroupsEndpoint = "/api/app/get_server_groups/v1"
+	GetPluginsEndpoint      = "/api/app/get_plugins/v1"
 )
 
+// ResponseCode is the code field of a Cronicle response. Cronicle sends 0 on
+// success and a string such as "event" or "api" on failure.
+type ResponseCode string
+
+func (c *ResponseCode) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = ResponseCode(s)
+		return nil
+	}
+	*c = ResponseCode(data)
+	return nil
+}
+
+// OK reports whether the response was successful
+func (c ResponseCode) OK() bool {
+	return c == "0"
+}
+
 type CreateEventResponse struct {
-	ID          string `json:"id"`
-	Code        int    `json:"code"`
-	Description string `json:"description,omitempty"`
+	ID          string       `json:"id"`
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
 }
 
 type StandardResponse struct {
-	Code        int    `json:"code"`
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+}
+
+type GetEventResponse struct {
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Event       *Event       `json:"event,omitempty"`
+}
+
+// RunEventResponse holds the IDs of the jobs started by run_event. Multiplexed events start
+// one job per server of the target group.
+type RunEventResponse struct {
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+	IDs         []string     `json:"ids"`
+}
+
+type GetJobStatusResponse struct {
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Job         *JobStatus   `json:"job,omitempty"`
+}
+
+// JobStatus is the state of an active or completed job
+type JobStatus struct {
+	Id    string `json:"id"`
+	Event string `json:"event"`
+	// Complete is 1 once the job finished
+	Complete int `json:"complete"`
+	// Code is 0 when the job succeeded, otherwise the exit status of the script or an error code
+	Code ResponseCode `json:"code"`
+	// Description is the error message of a failed job
 	Description string `json:"description,omitempty"`
+	// TimeStart and TimeEnd are Unix timestamps with fractional seconds
+	TimeStart float64 `json:"time_start,omitempty"`
+	TimeEnd   float64 `json:"time_end,omitempty"`
+	Elapsed   float64 `json:"elapsed,omitempty"`
+	Progress  float64 `json:"progress,omitempty"`
+}
+
+// Succeeded reports whether a complete job finished without error
+func (j *JobStatus) Succeeded() bool {
+	return j.Complete == 1 && (j.Code == "" || j.Code.OK())
+}
+
+type GetEventHistoryResponse struct {
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Rows        []JobStatus  `json:"rows"`
+}
+
+// ListResponse is a page of a Cronicle list, such as the schedule or the categories
+type ListResponse[T any] struct {
+	Code        ResponseCode `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Rows        []T          `json:"rows"`
+	List        struct {
+		Length int `json:"length"`
+	} `json:"list"`
+}
+
+// GetScheduleResponse is a page of the events of the schedule
+type GetScheduleResponse = ListResponse[Event]
+
+// listPageSize is the number of items fetched per call of a list endpoint
+const listPageSize = 500
+
+// Category is an event category. Its Title is the name shown in the Cronicle UI.
+type Category struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// ServerGroup is a group of servers events can target
+type ServerGroup struct {
+	Id     string `json:"id"`
+	Title  string `json:"title"`
+	Regexp string `json:"regexp,omitempty"`
+}
+
+// Plugin is a plugin events can run
+type Plugin struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Event is an event as stored by Cronicle
+type Event struct {
+	Id            string                 `json:"id"`
+	CatchUp       int                    `json:"catch_up"`
+	Category      string                 `json:"category"`
+	CpuLimit      int                    `json:"cpu_limit"`
+	CpuSustain    int                    `json:"cpu_sustain"`
+	Detached      int                    `json:"detached"`
+	Enabled       int                    `json:"enabled"`
+	LogMaxSize    int                    `json:"log_max_size"`
+	MaxChildren   int                    `json:"max_children"`
+	MemoryLimit   int                    `json:"memory_limit"`
+	MemorySustain int                    `json:"memory_sustain"`
+	Multiplex     int                    `json:"multiplex"`
+	Notes         string                 `json:"notes"`
+	NotifyFail    string                 `json:"notify_fail"`
+	NotifySuccess string                 `json:"notify_success"`
+	Params        map[string]interface{} `json:"params"`
+	Plugin        string                 `json:"plugin"`
+	Retries       int                    `json:"retries"`
+	RetryDelay    int                    `json:"retry_delay"`
+	Target        string                 `json:"target"`
+	Timeout       int                    `json:"timeout"`
+	Timezone      string                 `json:"timezone"`
+	Timing        CronicleTiming         `json:"timing"`
+	Title         string                 `json:"title"`
+	WebHook       string                 `json:"web_hook"`
+	Algorithm     string                 `json:"algorithm"`
+	OwnerUID      string                 `json:"k8s_owner_uid,omitempty"`
+	Created       int64                  `json:"created,omitempty"`
+	Modified      int64                  `json:"modified,omitempty"`
 }
 
 type Job struct {
@@ -30,7 +177,9 @@ type Job struct {
 }
 
 type JobsData struct {
-	Jobs map[string]Job `json:"jobs"`
+	Code        ResponseCode   `json:"code"`
+	Description string         `json:"description,omitempty"`
+	Jobs        map[string]Job `json:"jobs"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -43,238 +192,289 @@ type CronicleTiming struct {
 	Minutes  []int `json:"minutes,omitempty"`
 }
 
-// +k8s:deepcopy-gen=true
-type CronicleParams struct {
-	Script   string `json:"script,omitempty"`
-	Annotate int    `json:"annotate,omitempty"`
-	Json     int    `json:"json,omitempty"`
-}
-
 type CreateEventRequest struct {
-	CatchUp       int            `json:"catch_up"`
-	Category      string         `json:"category"`
-	CpuLimit      int            `json:"cpu_limit"`
-	CpuSustain    int            `json:"cpu_sustain"`
-	Detached      int            `json:"detached"`
-	Enabled       int            `json:"enabled"`
-	LogMaxSize    int            `json:"log_max_size"`
-	MaxChildren   int            `json:"max_children"`
-	MemoryLimit   int            `json:"memory_limit"`
-	MemorySustain int            `json:"memory_sustain"`
-	Multiplex     int            `json:"multiplex"`
-	Notes         string         `json:"notes"`
-	NotifyFail    string         `json:"notify_fail"`
-	NotifySuccess string         `json:"notify_success"`
-	Params        CronicleParams `json:"params"`
-	Plugin        string         `json:"plugin"`
-	Retries       int            `json:"retries"`
-	RetryDelay    int            `json:"retry_delay"`
-	Target        string         `json:"target"`
-	Timeout       int            `json:"timeout"`
-	Timezone      string         `json:"timezone"`
-	Timing        CronicleTiming `json:"timing"`
-	Title         string         `json:"title"`
-	WebHook       string         `json:"web_hook"`
-	Algorithm     string         `json:"algorithm"`
+	CatchUp       int                    `json:"catch_up"`
+	Category      string                 `json:"category"`
+	CpuLimit      int                    `json:"cpu_limit"`
+	CpuSustain    int                    `json:"cpu_sustain"`
+	Detached      int                    `json:"detached"`
+	Enabled       int                    `json:"enabled"`
+	LogMaxSize    int                    `json:"log_max_size"`
+	MaxChildren   int                    `json:"max_children"`
+	MemoryLimit   int                    `json:"memory_limit"`
+	MemorySustain int                    `json:"memory_sustain"`
+	Multiplex     int                    `json:"multiplex"`
+	Notes         string                 `json:"notes"`
+	NotifyFail    string                 `json:"notify_fail"`
+	NotifySuccess string                 `json:"notify_success"`
+	Params        map[string]interface{} `json:"params"`
+	Plugin        string                 `json:"plugin"`
+	Retries       int                    `json:"retries"`
+	RetryDelay    int                    `json:"retry_delay"`
+	Target        string                 `json:"target"`
+	Timeout       int                    `json:"timeout"`
+	Timezone      string                 `json:"timezone"`
+	Timing        CronicleTiming         `json:"timing"`
+	Title         string                 `json:"title"`
+	WebHook       string                 `json:"web_hook"`
+	Algorithm     string                 `json:"algorithm"`
+	// OwnerUID is the UID of the Kubernetes object the event is created for. Cronicle keeps
+	// it with the event, which lets the owner find the event again.
+	OwnerUID string `json:"k8s_owner_uid,omitempty"`
 }
 
 type UpdateEventRequest struct {
-	Id            string         `json:"id"`
-	CatchUp       int            `json:"catch_up"`
-	Category      string         `json:"category"`
-	CpuLimit      int            `json:"cpu_limit"`
-	CpuSustain    int            `json:"cpu_sustain"`
-	Detached      int            `json:"detached"`
-	Enabled       int            `json:"enabled"`
-	LogMaxSize    int            `json:"log_max_size"`
-	MaxChildren   int            `json:"max_children"`
-	MemoryLimit   int            `json:"memory_limit"`
-	MemorySustain int            `json:"memory_sustain"`
-	Multiplex     int            `json:"multiplex"`
-	Notes         string         `json:"notes"`
-	NotifyFail    string         `json:"notify_fail"`
-	NotifySuccess string         `json:"notify_success"`
-	Params        CronicleParams `json:"params"`
-	Plugin        string         `json:"plugin"`
-	Retries       int            `json:"retries"`
-	RetryDelay    int            `json:"retry_delay"`
-	Target        string         `json:"target"`
-	Timeout       int            `json:"timeout"`
-	Timezone      string         `json:"timezone"`
-	Timing        CronicleTiming `json:"timing"`
-	Title         string         `json:"title"`
-	WebHook       string         `json:"web_hook"`
-	Algorithm     string         `json:"algorithm"`
+	Id            string                 `json:"id"`
+	CatchUp       int                    `json:"catch_up"`
+	Category      string                 `json:"category"`
+	CpuLimit      int                    `json:"cpu_limit"`
+	CpuSustain    int                    `json:"cpu_sustain"`
+	Detached      int                    `json:"detached"`
+	Enabled       int                    `json:"enabled"`
+	LogMaxSize    int                    `json:"log_max_size"`
+	MaxChildren   int                    `json:"max_children"`
+	MemoryLimit   int                    `json:"memory_limit"`
+	MemorySustain int                    `json:"memory_sustain"`
+	Multiplex     int                    `json:"multiplex"`
+	Notes         string                 `json:"notes"`
+	NotifyFail    string                 `json:"notify_fail"`
+	NotifySuccess string                 `json:"notify_success"`
+	Params        map[string]interface{} `json:"params"`
+	Plugin        string                 `json:"plugin"`
+	Retries       int                    `json:"retries"`
+	RetryDelay    int                    `json:"retry_delay"`
+	Target        string                 `json:"target"`
+	Timeout       int                    `json:"timeout"`
+	Timezone      string                 `json:"timezone"`
+	Timing        CronicleTiming         `json:"timing"`
+	Title         string                 `json:"title"`
+	WebHook       string                 `json:"web_hook"`
+	Algorithm     string                 `json:"algorithm"`
+	// OwnerUID sets the ownership mark of the event when not empty, see CreateEventRequest.OwnerUID
+	OwnerUID string `json:"k8s_owner_uid,omitempty"`
 }
 
-// CreateEvent is a method that sends a request to the CreateEventEndpoint
-func (c *Client) CreateEvent(request CreateEventRequest) (string, error) {
-	url := fmt.Sprintf("%s%s", c.config.BaseUrl, CreateEventEndpoint)
-
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.config.APIKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+// RunEventRequest starts a job of an event right away. Params override the event's
+// plugin parameters for this job only.
+type RunEventRequest struct {
+	Id     string                 `json:"id"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected response code: %d", resp.StatusCode)
-	}
+// CreateEvent is a method that sends a request to the CreateEventEndpoint
+func (c *Client) CreateEvent(ctx context.Context, request CreateEventRequest) (string, error) {
 	var response CreateEventResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.do(ctx, http.MethodPost, CreateEventEndpoint, request, &response, false); err != nil {
 		return "", err
 	}
-	if response.Code != 0 {
-		return "", fmt.Errorf("Error when creating event: %s", response.Description)
+	if !response.Code.OK() {
+		return "", newAPIError(CreateEventEndpoint, response.Code, response.Description)
 	}
 	return response.ID, nil
 }
 
-func (c *Client) CheckRunningJobs(eventID string) (bool, error) {
-	url := fmt.Sprintf("%s%s", c.config.BaseUrl, getActiveJobsEndpoint)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return false, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.config.APIKey)
+func (c *Client) CheckRunningJobs(ctx context.Context, eventID string) (bool, error) {
+	jobIDs, err := c.GetActiveJobs(ctx, eventID)
+	return len(jobIDs) > 0, err
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return false, err
+// GetActiveJobs returns the IDs of the running jobs of an event, sorted
+func (c *Client) GetActiveJobs(ctx context.Context, eventID string) ([]string, error) {
+	var response JobsData
+	if err := c.do(ctx, http.MethodGet, GetActiveJobsEndpoint, nil, &response, true); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	if !response.Code.OK() {
+		return nil, newAPIError(GetActiveJobsEndpoint, response.Code, response.Description)
 	}
 
-	var response JobsData
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return false, err
-	}
-
-	for _, job := range response.Jobs {
+	var jobIDs []string
+	for id, job := range response.Jobs {
 		if job.Event == eventID {
-			return true, nil
+			jobIDs = append(jobIDs, id)
 		}
 	}
-	return false, nil
+	sort.Strings(jobIDs)
+	return jobIDs, nil
 }
 
-func (c *Client) DeleteEvent(eventID string) error {
-	url := fmt.Sprintf("%s%s", c.config.BaseUrl, DeleteEventEndpoint)
-
-	jsonData, err := json.Marshal(map[string]string{"id": eventID})
-	if err != nil {
+// AbortJob aborts a running job
+func (c *Client) AbortJob(ctx context.Context, jobID string) error {
+	var response StandardResponse
+	if err := c.do(ctx, http.MethodPost, AbortJobEndpoint, map[string]string{"id": jobID}, &response, true); err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	if !response.Code.OK() {
+		return newAPIError(AbortJobEndpoint, response.Code, response.Description)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.config.APIKey)
+	return nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+func (c *Client) DeleteEvent(ctx context.Context, eventID string) error {
+	var response StandardResponse
+	if err := c.do(ctx, http.MethodPost, DeleteEventEndpoint, map[string]string{"id": eventID}, &response, true); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected response code when deleting event: %d", resp.StatusCode)
+	if !response.Code.OK() {
+		return newAPIError(DeleteEventEndpoint, response.Code, response.Description)
 	}
+	return nil
+}
+
+func (c *Client) DisableEvent(ctx context.Context, eventID string) error {
 	var response StandardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := c.do(ctx, http.MethodPost, UpdateEventEndpoint, map[string]interface{}{"id": eventID, "enabled": 0}, &response, true); err != nil {
 		return err
 	}
 
-	if response.Code != 0 {
-		return fmt.Errorf("Error when deleting event: %s", response.Description)
+	if !response.Code.OK() {
+		return newAPIError(UpdateEventEndpoint, response.Code, response.Description)
 	}
 	return nil
 }
 
-func (c *Client) DisableEvent(eventID string) error {
-	url := fmt.Sprintf("%s%s", c.config.BaseUrl, UpdateEventEndpoint)
-
-	jsonData, err := json.Marshal(map[string]interface{}{"id": eventID, "enabled": 0})
-	if err != nil {
+// ReleaseEvent removes the ownership mark set by CreateEventRequest.OwnerUID from an event
+func (c *Client) ReleaseEvent(ctx context.Context, eventID string) error {
+	var response StandardResponse
+	if err := c.do(ctx, http.MethodPost, UpdateEventEndpoint, map[string]interface{}{"id": eventID, "k8s_owner_uid": ""}, &response, true); err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	if !response.Code.OK() {
+		return newAPIError(UpdateEventEndpoint, response.Code, response.Description)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.config.APIKey)
+	return nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+func (c *Client) UpdateEvent(ctx context.Context, request UpdateEventRequest) error {
+	var response StandardResponse
+	if err := c.do(ctx, http.MethodPost, UpdateEventEndpoint, request, &response, true); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	if !response.Code.OK() {
+		return newAPIError(UpdateEventEndpoint, response.Code, response.Description)
 	}
-	var response StandardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return err
+	return nil
+}
+
+// GetEvent fetches a single event from Cronicle
+func (c *Client) GetEvent(ctx context.Context, eventID string) (*Event, error) {
+	var response GetEventResponse
+	if err := c.do(ctx, http.MethodPost, GetEventEndpoint, map[string]string{"id": eventID}, &response, true); err != nil {
+		return nil, err
 	}
 
-	if response.Code != 0 {
-		return fmt.Errorf("Error when disabling event: %s", response.Description)
+	if !response.Code.OK() {
+		return nil, newAPIError(GetEventEndpoint, response.Code, response.Description)
 	}
-	return nil
+	if response.Event == nil {
+		return nil, fmt.Errorf("%s: response has no event", GetEventEndpoint)
+	}
+	return response.Event, nil
 }
 
-func (c *Client) UpdateEvent(request UpdateEventRequest) error {
-	url := fmt.Sprintf("%s%s", c.config.BaseUrl, UpdateEventEndpoint)
+// RunEvent starts the event immediately and returns the IDs of the started jobs. It is not
+// retried on server errors since Cronicle may already have started the jobs.
+func (c *Client) RunEvent(ctx context.Context, request RunEventRequest) ([]string, error) {
+	var response RunEventResponse
+	if err := c.do(ctx, http.MethodPost, RunEventEndpoint, request, &response, false); err != nil {
+		return nil, err
+	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return err
+	if !response.Code.OK() {
+		return nil, newAPIError(RunEventEndpoint, response.Code, response.Description)
 	}
+	return response.IDs, nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+// GetJobStatus fetches the state of an active or completed job
+func (c *Client) GetJobStatus(ctx context.Context, jobID string) (*JobStatus, error) {
+	var response GetJobStatusResponse
+	if err := c.do(ctx, http.MethodPost, GetJobStatusEndpoint, map[string]string{"id": jobID}, &response, true); err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.config.APIKey)
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
+	if !response.Code.OK() {
+		return nil, newAPIError(GetJobStatusEndpoint, response.Code, response.Description)
+	}
+	if response.Job == nil {
+		return nil, fmt.Errorf("%s: response has no job", GetJobStatusEndpoint)
 	}
-	defer resp.Body.Close()
+	return response.Job, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+// GetJobLog returns at most the last tailBytes bytes of the log of a completed job. Cronicle
+// only stores the log once the job finished.
+func (c *Client) GetJobLog(ctx context.Context, jobID string, tailBytes int) (string, error) {
+	var log []byte
+	endpoint := GetJobLogEndpoint + "?id=" + url.QueryEscape(jobID)
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &log, true); err != nil {
+		return "", err
 	}
-	var response StandardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return err
+	if tailBytes > 0 && len(log) > tailBytes {
+		log = log[len(log)-tailBytes:]
 	}
+	return string(log), nil
+}
 
-	if response.Code != 0 {
-		return fmt.Errorf("Error when updating event: %s", response.Description)
+// GetEventHistory returns up to limit completed jobs of an event, most recent first
+func (c *Client) GetEventHistory(ctx context.Context, eventID string, limit int) ([]JobStatus, error) {
+	var response GetEventHistoryResponse
+	request := map[string]interface{}{"id": eventID, "offset": 0, "limit": limit}
+	if err := c.do(ctx, http.MethodPost, GetEventHistoryEndpoint, request, &response, true); err != nil {
+		return nil, err
+	}
+
+	if !response.Code.OK() {
+		return nil, newAPIError(GetEventHistoryEndpoint, response.Code, response.Description)
+	}
+	// The history only holds finished jobs but does not flag them as complete
+	for i := range response.Rows {
+		response.Rows[i].Complete = 1
+	}
+	return response.Rows, nil
+}
+
+// GetSchedule returns all events of the schedule
+func (c *Client) GetSchedule(ctx context.Context) ([]Event, error) {
+	return listAll[Event](ctx, c, GetScheduleEndpoint)
+}
+
+// GetCategories returns all event categories
+func (c *Client) GetCategories(ctx context.Context) ([]Category, error) {
+	return listAll[Category](ctx, c, GetCategoriesEndpoint)
+}
+
+// GetServerGroups returns all server groups
+func (c *Client) GetServerGroups(ctx context.Context) ([]ServerGroup, error) {
+	return listAll[ServerGroup](ctx, c, GetServerGroupsEndpoint)
+}
+
+// GetPlugins returns all plugins
+func (c *Client) GetPlugins(ctx context.Context) ([]Plugin, error) {
+	return listAll[Plugin](ctx, c, GetPluginsEndpoint)
+}
+
+// listAll fetches every page of a list endpoint
+func listAll[T any](ctx context.Context, c *Client, endpoint string) ([]T, error) {
+	var items []T
+	for {
+		var response ListResponse[T]
+		request := map[string]interface{}{"offset": len(items), "limit": listPageSize}
+		if err := c.do(ctx, http.MethodPost, endpoint, request, &response, true); err != nil {
+			return nil, err
+		}
+
+		if !response.Code.OK() {
+			return nil, newAPIError(endpoint, response.Code, response.Description)
+		}
+		items = append(items, response.Rows...)
+		if len(response.Rows) == 0 || len(items) >= response.List.Length {
+			return items, nil
+		}
 	}
-	return nil
 }
@@ -0,0 +1,94 @@
+package cronicle_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when Cronicle rejects a request, either with a non 200 HTTP
+// status or with a non zero code in the response body
+type APIError struct {
+	// Endpoint is the API path that was called
+	Endpoint string
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the Cronicle error code, e.g. "event", "job", "api" or "session"
+	Code string
+	// Description is the human readable error sent by Cronicle
+	Description string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s: unexpected response code: %d", e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Endpoint, e.Code, e.Description)
+}
+
+// newAPIError builds the error of a response carrying a non zero Cronicle code
+func newAPIError(endpoint string, code ResponseCode, description string) *APIError {
+	return &APIError{
+		Endpoint:    endpoint,
+		StatusCode:  http.StatusOK,
+		Code:        string(code),
+		Description: description,
+	}
+}
+
+func asAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsNotFound reports whether Cronicle could not find the event or job a request referred to
+func IsNotFound(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || strings.HasPrefix(apiErr.Description, "Failed to locate")
+}
+
+// IsUnauthorized reports whether Cronicle rejected the API key
+func IsUnauthorized(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized, apiErr.StatusCode == http.StatusForbidden:
+		return true
+	case apiErr.Code == "session":
+		return true
+	case apiErr.Code == "api":
+		return strings.Contains(strings.ToLower(apiErr.Description), "key")
+	}
+	return false
+}
+
+// IsConflict reports whether the request clashed with existing state in Cronicle
+func IsConflict(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusConflict || strings.Contains(apiErr.Description, "already exists")
+}
+
+// IsTransient reports whether a request failed for a reason that may go away on its own:
+// a network error, a 5xx response or rate limiting
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apiErr, ok := asAPIError(err); ok {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
@@ -0,0 +1,466 @@
+// Package fake provides an in-memory Cronicle API for tests. The Server speaks the same
+// HTTP protocol as a Cronicle master, so the real cronicle_client.Client can be pointed at it.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// APIKey is the API key accepted by a Server
+const APIKey = "fake-api-key"
+
+// Job is an active or completed job of the fake Cronicle
+type Job struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Title string `json:"event_title,omitempty"`
+	// Params are the plugin parameters of the job, the event's params merged with overrides
+	Params      map[string]interface{} `json:"params,omitempty"`
+	TimeStart   float64                `json:"time_start"`
+	TimeEnd     float64                `json:"time_end,omitempty"`
+	Complete    int                    `json:"complete"`
+	Code        int                    `json:"code"`
+	Description string                 `json:"description,omitempty"`
+	Elapsed     float64                `json:"elapsed,omitempty"`
+
+	// seq orders the jobs by start
+	seq int
+}
+
+// Server is a stateful emulation of the Cronicle API endpoints used by the operator
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	nextID int
+	events map[string]map[string]interface{}
+	jobs   map[string]*Job
+	logs   map[string]string
+	calls  map[string]int
+
+	// categories, serverGroups and plugins are sorted by ID
+	categories   []cronicle_client.Category
+	serverGroups []cronicle_client.ServerGroup
+	plugins      []cronicle_client.Plugin
+}
+
+// NewServer starts a Server. Callers must Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		events: map[string]map[string]interface{}{},
+		jobs:   map[string]*Job{},
+		logs:   map[string]string{},
+		calls:  map[string]int{},
+
+		// The ones a new Cronicle installation starts with
+		categories:   []cronicle_client.Category{{Id: "general", Title: "General"}},
+		serverGroups: []cronicle_client.ServerGroup{{Id: "allgrp", Title: "All Servers", Regexp: ".+"}, {Id: "maingrp", Title: "Master Group", Regexp: "^(localhost)$"}},
+		plugins:      []cronicle_client.Plugin{{Id: "shellplug", Title: "Shell Script"}, {Id: "testplug", Title: "Test Plugin"}, {Id: "urlplug", Title: "HTTP Request"}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cronicle_client.CreateEventEndpoint, s.handle(s.createEvent))
+	mux.HandleFunc(cronicle_client.UpdateEventEndpoint, s.handle(s.updateEvent))
+	mux.HandleFunc(cronicle_client.DeleteEventEndpoint, s.handle(s.deleteEvent))
+	mux.HandleFunc(cronicle_client.GetEventEndpoint, s.handle(s.getEvent))
+	mux.HandleFunc(cronicle_client.GetActiveJobsEndpoint, s.handle(s.getActiveJobs))
+	mux.HandleFunc(cronicle_client.RunEventEndpoint, s.handle(s.runEvent))
+	mux.HandleFunc(cronicle_client.GetJobStatusEndpoint, s.handle(s.getJobStatus))
+	mux.HandleFunc(cronicle_client.GetJobLogEndpoint, s.getJobLog)
+	mux.HandleFunc(cronicle_client.GetEventHistoryEndpoint, s.handle(s.getEventHistory))
+	mux.HandleFunc(cronicle_client.GetScheduleEndpoint, s.handle(s.getSchedule))
+	mux.HandleFunc(cronicle_client.AbortJobEndpoint, s.handle(s.abortJob))
+	mux.HandleFunc(cronicle_client.GetCategoriesEndpoint, s.handle(func(params map[string]interface{}) (map[string]interface{}, error) {
+		return listPage(s.categories, params), nil
+	}))
+	mux.HandleFunc(cronicle_client.GetServerGroupsEndpoint, s.handle(func(params map[string]interface{}) (map[string]interface{}, error) {
+		return listPage(s.serverGroups, params), nil
+	}))
+	mux.HandleFunc(cronicle_client.GetPluginsEndpoint, s.handle(func(params map[string]interface{}) (map[string]interface{}, error) {
+		return listPage(s.plugins, params), nil
+	}))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config returns a client configuration pointing at the server
+func (s *Server) Config() cronicle_client.Config {
+	return cronicle_client.Config{
+		BaseUrl: s.URL,
+		APIKey:  APIKey,
+		Timeout: 5 * time.Second,
+	}
+}
+
+// Event returns a copy of an event as stored by the server, or nil when it does not exist
+func (s *Server) Event(id string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok {
+		return nil
+	}
+	return copyFields(event)
+}
+
+// EventIDs returns the IDs of all stored events, sorted
+func (s *Server) EventIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.events))
+	for id := range s.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetEventField changes a field of a stored event the way an edit in the Cronicle UI would
+func (s *Server) SetEventField(id, field string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event, ok := s.events[id]; ok {
+		event[field] = value
+	}
+}
+
+// AddCategory adds an event category
+func (s *Server) AddCategory(id, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = append(s.categories, cronicle_client.Category{Id: id, Title: title})
+	sort.Slice(s.categories, func(i, j int) bool { return s.categories[i].Id < s.categories[j].Id })
+}
+
+// AddServerGroup adds a server group
+func (s *Server) AddServerGroup(id, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverGroups = append(s.serverGroups, cronicle_client.ServerGroup{Id: id, Title: title})
+	sort.Slice(s.serverGroups, func(i, j int) bool { return s.serverGroups[i].Id < s.serverGroups[j].Id })
+}
+
+// AddPlugin adds a plugin
+func (s *Server) AddPlugin(id, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plugins = append(s.plugins, cronicle_client.Plugin{Id: id, Title: title})
+	sort.Slice(s.plugins, func(i, j int) bool { return s.plugins[i].Id < s.plugins[j].Id })
+}
+
+// RemoveEvent deletes an event behind the operator's back
+func (s *Server) RemoveEvent(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, id)
+}
+
+// StartJob starts an active job for an event and returns its ID
+func (s *Server) StartJob(eventID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.startJob(eventID, nil)
+}
+
+// ActiveJobs returns the active jobs of an event, sorted by ID
+func (s *Server) ActiveJobs(eventID string) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := []Job{}
+	for _, job := range s.jobs {
+		if job.Event == eventID && job.Complete == 0 {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+// FinishJob completes a job successfully
+func (s *Server) FinishJob(jobID string) {
+	s.CompleteJob(jobID, 0, "", "")
+}
+
+// CompleteJob completes a job with a result code, an error description and its log
+func (s *Server) CompleteJob(jobID string, code int, description, log string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[jobID]; ok {
+		s.completeJob(job, code, description, log)
+	}
+}
+
+func (s *Server) completeJob(job *Job, code int, description, log string) {
+	job.Complete = 1
+	job.Code = code
+	job.Description = description
+	job.TimeEnd = float64(time.Now().Unix())
+	job.Elapsed = job.TimeEnd - job.TimeStart
+	s.logs[job.ID] = log
+}
+
+// Calls returns how many times an endpoint was called
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[endpoint]
+}
+
+type handlerFunc func(params map[string]interface{}) (map[string]interface{}, error)
+
+// cronicleError is turned into a Cronicle error response
+type cronicleError struct {
+	code        string
+	description string
+}
+
+func (e *cronicleError) Error() string {
+	return e.description
+}
+
+func notFound(kind, id string) error {
+	return &cronicleError{code: kind, description: fmt.Sprintf("Failed to locate %s: %s", kind, id)}
+}
+
+func (s *Server) handle(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		params := map[string]interface{}{}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		for key, values := range r.URL.Query() {
+			params[key] = values[0]
+		}
+
+		s.mu.Lock()
+		s.calls[r.URL.Path]++
+		var response map[string]interface{}
+		var err error
+		if r.Header.Get("X-Api-Key") != APIKey {
+			err = &cronicleError{code: "api", description: "Invalid API Key: " + r.Header.Get("X-Api-Key")}
+		} else {
+			response, err = fn(params)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			ce, ok := err.(*cronicleError)
+			if !ok {
+				ce = &cronicleError{code: "server", description: err.Error()}
+			}
+			response = map[string]interface{}{"code": ce.code, "description": ce.description}
+		} else {
+			if response == nil {
+				response = map[string]interface{}{}
+			}
+			response["code"] = 0
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", prefix, s.nextID)
+}
+
+func (s *Server) createEvent(params map[string]interface{}) (map[string]interface{}, error) {
+	for _, field := range []string{"title", "category", "plugin", "target"} {
+		if params[field] == nil || params[field] == "" {
+			return nil, &cronicleError{code: "api", description: "Missing parameter: " + field}
+		}
+	}
+
+	id, _ := params["id"].(string)
+	if id == "" {
+		id = s.newID("e")
+	} else if _, ok := s.events[id]; ok {
+		return nil, &cronicleError{code: "event", description: "Event with that ID already exists: " + id}
+	}
+
+	event := copyFields(params)
+	now := time.Now().Unix()
+	event["id"] = id
+	event["created"] = now
+	event["modified"] = now
+	s.events[id] = event
+	return map[string]interface{}{"id": id}, nil
+}
+
+func (s *Server) updateEvent(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	event, ok := s.events[id]
+	if !ok {
+		return nil, notFound("event", id)
+	}
+	for key, value := range params {
+		event[key] = value
+	}
+	event["modified"] = time.Now().Unix()
+	return nil, nil
+}
+
+func (s *Server) deleteEvent(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	if _, ok := s.events[id]; !ok {
+		return nil, notFound("event", id)
+	}
+	for _, job := range s.jobs {
+		if job.Event == id && job.Complete == 0 {
+			return nil, &cronicleError{code: "event", description: "Cannot delete event with active jobs: " + id}
+		}
+	}
+	delete(s.events, id)
+	return nil, nil
+}
+
+func (s *Server) getEvent(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	event, ok := s.events[id]
+	if !ok {
+		return nil, notFound("event", id)
+	}
+	return map[string]interface{}{"event": copyFields(event)}, nil
+}
+
+func (s *Server) getActiveJobs(map[string]interface{}) (map[string]interface{}, error) {
+	jobs := map[string]Job{}
+	for id, job := range s.jobs {
+		if job.Complete == 0 {
+			jobs[id] = *job
+		}
+	}
+	return map[string]interface{}{"jobs": jobs}, nil
+}
+
+func (s *Server) getJobStatus(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, notFound("job", id)
+	}
+	return map[string]interface{}{"job": *job}, nil
+}
+
+func (s *Server) getEventHistory(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	offset, _ := params["offset"].(float64)
+	limit, _ := params["limit"].(float64)
+
+	rows := []Job{}
+	for _, job := range s.jobs {
+		if job.Event == id && job.Complete == 1 {
+			rows = append(rows, *job)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq > rows[j].seq })
+	total := len(rows)
+	rows = rows[min(int(offset), total):]
+	if limit > 0 && int(limit) < len(rows) {
+		rows = rows[:int(limit)]
+	}
+	return map[string]interface{}{"rows": rows, "list": map[string]interface{}{"length": total}}, nil
+}
+
+func (s *Server) getSchedule(params map[string]interface{}) (map[string]interface{}, error) {
+	offset, _ := params["offset"].(float64)
+	limit, _ := params["limit"].(float64)
+
+	ids := make([]string, 0, len(s.events))
+	for id := range s.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	total := len(ids)
+	ids = ids[min(int(offset), total):]
+	if limit > 0 && int(limit) < len(ids) {
+		ids = ids[:int(limit)]
+	}
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, copyFields(s.events[id]))
+	}
+	return map[string]interface{}{"rows": rows, "list": map[string]interface{}{"length": total}}, nil
+}
+
+// listPage serves the page of items selected by the offset and limit params of a list call
+func listPage[T any](items []T, params map[string]interface{}) map[string]interface{} {
+	offset, _ := params["offset"].(float64)
+	limit, _ := params["limit"].(float64)
+
+	total := len(items)
+	rows := items[min(int(offset), total):]
+	if limit > 0 && int(limit) < len(rows) {
+		rows = rows[:int(limit)]
+	}
+	return map[string]interface{}{"rows": rows, "list": map[string]interface{}{"length": total}}
+}
+
+func (s *Server) abortJob(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	job, ok := s.jobs[id]
+	if !ok || job.Complete == 1 {
+		return nil, notFound("job", id)
+	}
+	s.completeJob(job, 1, "Job Aborted: by user", "")
+	return nil, nil
+}
+
+// getJobLog serves the plain text log of a completed job
+func (s *Server) getJobLog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[r.URL.Path]++
+	log, ok := s.logs[r.URL.Query().Get("id")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(log))
+}
+
+func (s *Server) runEvent(params map[string]interface{}) (map[string]interface{}, error) {
+	id, _ := params["id"].(string)
+	if _, ok := s.events[id]; !ok {
+		return nil, notFound("event", id)
+	}
+	overrides, _ := params["params"].(map[string]interface{})
+	return map[string]interface{}{"ids": []string{s.startJob(id, overrides)}}, nil
+}
+
+func (s *Server) startJob(eventID string, overrides map[string]interface{}) string {
+	id := s.newID("j")
+	event := s.events[eventID]
+	title, _ := event["title"].(string)
+	params := map[string]interface{}{}
+	if eventParams, ok := event["params"].(map[string]interface{}); ok {
+		params = copyFields(eventParams)
+	}
+	for key, value := range overrides {
+		params[key] = value
+	}
+	s.jobs[id] = &Job{ID: id, Event: eventID, Title: title, Params: params, TimeStart: float64(time.Now().Unix()), seq: s.nextID}
+	return id
+}
+
+// copyFields returns a deep copy of a JSON object
+func copyFields(in map[string]interface{}) map[string]interface{} {
+	data, _ := json.Marshal(in)
+	out := map[string]interface{}{}
+	_ = json.Unmarshal(data, &out)
+	return out
+}
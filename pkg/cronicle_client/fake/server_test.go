@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// call posts params to an endpoint of the server and returns the HTTP status and the decoded response
+func call(t *testing.T, s *Server, endpoint, apiKey string, params map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(params)
+	request, _ := http.NewRequest(http.MethodPost, s.URL+endpoint, bytes.NewReader(body))
+	request.Header.Set("X-API-Key", apiKey)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	decoded := map[string]interface{}{}
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, decoded
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func createEvent(t *testing.T, s *Server, params map[string]interface{}) string {
+	t.Helper()
+	event := map[string]interface{}{"title": "T", "category": "general", "plugin": "shellplug", "target": "allgrp"}
+	for key, value := range params {
+		event[key] = value
+	}
+	_, response := call(t, s, cronicle_client.CreateEventEndpoint, APIKey, event)
+	id, _ := response["id"].(string)
+	if response["code"] != float64(0) || id == "" {
+		t.Fatalf("create_event failed: %v", response)
+	}
+	return id
+}
+
+func TestErrorsAreReturnedWithStatusOK(t *testing.T) {
+	s := newTestServer(t)
+	id := createEvent(t, s, nil)
+	s.StartJob(id)
+
+	for _, tc := range []struct {
+		name     string
+		endpoint string
+		apiKey   string
+		params   map[string]interface{}
+		code     string
+	}{
+		{"invalid API key", cronicle_client.GetActiveJobsEndpoint, "nope", nil, "api"},
+		{"missing event", cronicle_client.GetEventEndpoint, APIKey, map[string]interface{}{"id": "missing"}, "event"},
+		{"missing job", cronicle_client.AbortJobEndpoint, APIKey, map[string]interface{}{"id": "missing"}, "job"},
+		{"missing parameter", cronicle_client.CreateEventEndpoint, APIKey, map[string]interface{}{"title": "T"}, "api"},
+		{"active jobs", cronicle_client.DeleteEventEndpoint, APIKey, map[string]interface{}{"id": id}, "event"},
+	} {
+		status, response := call(t, s, tc.endpoint, tc.apiKey, tc.params)
+		if status != http.StatusOK || response["code"] != tc.code || response["description"] == "" {
+			t.Errorf("%s: expected HTTP 200 with code %q, got %d %v", tc.name, tc.code, status, response)
+		}
+	}
+}
+
+func TestClientSeesErrorsOfTheFake(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := cronicle_client.NewClient(s.Config()).GetEvent(ctx, "missing")
+	if !cronicle_client.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	config := s.Config()
+	config.APIKey = "nope"
+	if _, err := cronicle_client.NewClient(config).GetActiveJobs(ctx, "e1"); !cronicle_client.IsUnauthorized(err) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestRunEventOverridesEventParams(t *testing.T) {
+	s := newTestServer(t)
+	id := createEvent(t, s, map[string]interface{}{"params": map[string]interface{}{"script": "echo hi", "annotate": 1}})
+
+	_, response := call(t, s, cronicle_client.RunEventEndpoint, APIKey, map[string]interface{}{
+		"id":     id,
+		"params": map[string]interface{}{"script": "echo override"},
+	})
+	ids, _ := response["ids"].([]interface{})
+	if response["code"] != float64(0) || len(ids) != 1 {
+		t.Fatalf("run_event failed: %v", response)
+	}
+
+	jobs := s.ActiveJobs(id)
+	if len(jobs) != 1 || jobs[0].ID != ids[0] {
+		t.Fatalf("expected the started job to be active, got %+v", jobs)
+	}
+	if jobs[0].Params["script"] != "echo override" || jobs[0].Params["annotate"] != float64(1) {
+		t.Fatalf("expected the overrides merged into the event params, got %v", jobs[0].Params)
+	}
+	if params := s.Event(id)["params"].(map[string]interface{}); params["script"] != "echo hi" {
+		t.Fatalf("run_event must not change the event, got %v", params)
+	}
+}
+
+func TestActiveJobsAndAbort(t *testing.T) {
+	s := newTestServer(t)
+	id := createEvent(t, s, nil)
+	running := s.StartJob(id)
+	s.FinishJob(s.StartJob(id))
+
+	_, response := call(t, s, cronicle_client.GetActiveJobsEndpoint, APIKey, nil)
+	jobs, _ := response["jobs"].(map[string]interface{})
+	if len(jobs) != 1 || jobs[running] == nil {
+		t.Fatalf("expected only %s to be active, got %v", running, response)
+	}
+
+	_, response = call(t, s, cronicle_client.AbortJobEndpoint, APIKey, map[string]interface{}{"id": running})
+	if response["code"] != float64(0) || len(s.ActiveJobs(id)) != 0 {
+		t.Fatalf("abort_job failed: %v", response)
+	}
+	_, response = call(t, s, cronicle_client.AbortJobEndpoint, APIKey, map[string]interface{}{"id": running})
+	if response["code"] != "job" {
+		t.Fatalf("expected aborting a finished job to fail, got %v", response)
+	}
+}
@@ -22,21 +22,6 @@ package cronicle_client
 
 import ()
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CronicleParams) DeepCopyInto(out *CronicleParams) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronicleParams.
-func (in *CronicleParams) DeepCopy() *CronicleParams {
-	if in == nil {
-		return nil
-	}
-	out := new(CronicleParams)
-	in.DeepCopyInto(out)
-	return out
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CronicleTiming) DeepCopyInto(out *CronicleTiming) {
 	*out = *in
@@ -0,0 +1,179 @@
+// Package schedule translates cron expressions into Cronicle timings.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// macros are the supported cron shorthands
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes one of the five fields of a cron expression
+type field struct {
+	name string
+	min  int
+	max  int
+	// names are accepted in place of the values starting at min
+	names []string
+	// wraps is set when max is another name for min, like 7 for Sunday
+	wraps bool
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}, wraps: true}
+)
+
+// Parse converts a standard five field cron expression ("minute hour day-of-month month
+// day-of-week") or one of the macros @yearly, @annually, @monthly, @weekly, @daily, @midnight
+// and @hourly into the equivalent Cronicle timing. Fields support lists, ranges, steps and
+// month and weekday names. Fields matching every value are left empty in the timing.
+//
+// Cron runs a job when either the day of month or the day of week matches if both are
+// restricted, while Cronicle requires both to match, so such expressions are rejected.
+func Parse(expr string) (cronicle_client.CronicleTiming, error) {
+	var timing cronicle_client.CronicleTiming
+
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		expanded, ok := macros[strings.ToLower(expr)]
+		if !ok {
+			return timing, fmt.Errorf("unsupported macro %q", expr)
+		}
+		expr = expanded
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return timing, fmt.Errorf("expected 5 fields in %q, found %d", expr, len(fields))
+	}
+	if !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*") {
+		return timing, fmt.Errorf("day of month and day of week cannot both be restricted: Cronicle only runs when both match")
+	}
+
+	var err error
+	if timing.Minutes, err = minuteField.parse(fields[0]); err != nil {
+		return timing, err
+	}
+	if timing.Hours, err = hourField.parse(fields[1]); err != nil {
+		return timing, err
+	}
+	if timing.Days, err = dayField.parse(fields[2]); err != nil {
+		return timing, err
+	}
+	if timing.Months, err = monthField.parse(fields[3]); err != nil {
+		return timing, err
+	}
+	if timing.Weekdays, err = weekdayField.parse(fields[4]); err != nil {
+		return timing, err
+	}
+	return timing, nil
+}
+
+// parse returns the sorted values matched by a field, or nil when it matches every value
+func (f field) parse(expr string) ([]int, error) {
+	matched := map[int]bool{}
+	for _, part := range strings.Split(expr, ",") {
+		if err := f.parsePart(part, matched); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", f.name, expr, err)
+		}
+	}
+
+	count := f.max - f.min + 1
+	if f.wraps {
+		if matched[f.max] {
+			delete(matched, f.max)
+			matched[f.min] = true
+		}
+		count--
+	}
+	if len(matched) == count {
+		return nil, nil
+	}
+
+	values := make([]int, 0, len(matched))
+	for value := range matched {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+// parsePart adds the values of a single list element such as 5, 1-5, */15 or 10-30/5
+func (f field) parsePart(part string, matched map[int]bool) error {
+	rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step < 1 {
+			return fmt.Errorf("invalid step %q", stepExpr)
+		}
+	}
+
+	var start, end int
+	switch {
+	case rangeExpr == "*":
+		start, end = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		startExpr, endExpr, _ := strings.Cut(rangeExpr, "-")
+		var err error
+		if start, err = f.value(startExpr); err != nil {
+			return err
+		}
+		if end, err = f.value(endExpr); err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("range %q is backwards", rangeExpr)
+		}
+	default:
+		var err error
+		if start, err = f.value(rangeExpr); err != nil {
+			return err
+		}
+		end = start
+		if hasStep {
+			// 5/15 means every 15 starting at 5
+			end = f.max
+		}
+	}
+
+	for value := start; value <= end; value += step {
+		matched[value] = true
+	}
+	return nil
+}
+
+// value parses a number or a name of the field
+func (f field) value(expr string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(expr, name) {
+			return f.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", expr)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", value, f.min, f.max)
+	}
+	return value, nil
+}
@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want cronicle_client.CronicleTiming
+	}{
+		{expr: "* * * * *", want: cronicle_client.CronicleTiming{}},
+		{expr: "*/15 * * * *", want: cronicle_client.CronicleTiming{Minutes: []int{0, 15, 30, 45}}},
+		{expr: "0 9-17/4 * * MON-FRI", want: cronicle_client.CronicleTiming{Minutes: []int{0}, Hours: []int{9, 13, 17}, Weekdays: []int{1, 2, 3, 4, 5}}},
+		{expr: "5,35 0 1 jan,Jul *", want: cronicle_client.CronicleTiming{Minutes: []int{5, 35}, Hours: []int{0}, Days: []int{1}, Months: []int{1, 7}}},
+		{expr: "10/20 * * * 7", want: cronicle_client.CronicleTiming{Minutes: []int{10, 30, 50}, Weekdays: []int{0}}},
+		{expr: "0 0 * * 0-7", want: cronicle_client.CronicleTiming{Minutes: []int{0}, Hours: []int{0}}},
+		{expr: "0-59 * */2 * *", want: cronicle_client.CronicleTiming{Days: []int{1, 3, 5, 7, 9, 11, 13, 15, 17, 19, 21, 23, 25, 27, 29, 31}}},
+		{expr: "@daily", want: cronicle_client.CronicleTiming{Minutes: []int{0}, Hours: []int{0}}},
+		{expr: "@HOURLY", want: cronicle_client.CronicleTiming{Minutes: []int{0}}},
+		{expr: "@yearly", want: cronicle_client.CronicleTiming{Minutes: []int{0}, Hours: []int{0}, Days: []int{1}, Months: []int{1}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"0 0 1 * 1",
+		"@reboot",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected an error", expr)
+		}
+	}
+}
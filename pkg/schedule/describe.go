@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// maxListedTimes is the largest number of times of day listed one by one, e.g. "at 09:00, 17:30"
+const maxListedTimes = 4
+
+var (
+	monthNames   = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+)
+
+// Describe returns a short English description of a timing, such as
+// "every 5 minutes, Mon–Fri" or "at 02:30, on day 1 of the month"
+func Describe(timing cronicle_client.CronicleTiming) string {
+	parts := []string{describeTime(timing.Minutes, timing.Hours)}
+	if len(timing.Weekdays) > 0 {
+		parts = append(parts, formatList(timing.Weekdays, func(v int) string { return weekdayNames[v%7] }))
+	}
+	if len(timing.Days) > 0 {
+		parts = append(parts, "on day "+formatList(timing.Days, strconv.Itoa)+" of the month")
+	}
+	if len(timing.Months) > 0 {
+		parts = append(parts, "in "+formatList(timing.Months, func(v int) string { return monthNames[(v+11)%12] }))
+	}
+	if len(timing.Years) > 0 {
+		parts = append(parts, "in "+formatList(timing.Years, strconv.Itoa))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeTime describes the minutes and hours fields
+func describeTime(minutes, hours []int) string {
+	var minutePart string
+	if len(minutes) == 0 {
+		minutePart = "every minute"
+	} else if step, ok := evenStep(minutes, 60); ok && step > 1 {
+		minutePart = fmt.Sprintf("every %d minutes", step)
+	}
+
+	switch {
+	case len(hours) == 0 && minutePart != "":
+		return minutePart
+	case len(hours) == 0:
+		return "at " + formatList(minutes, func(v int) string { return fmt.Sprintf(":%02d", v) }) + " past every hour"
+	case minutePart != "":
+		return minutePart + duringHours(hours)
+	case len(minutes)*len(hours) <= maxListedTimes:
+		var times []string
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				times = append(times, fmt.Sprintf("%02d:%02d", hour, minute))
+			}
+		}
+		return "at " + strings.Join(times, ", ")
+	}
+	if step, ok := evenStep(hours, 24); ok && step > 1 {
+		return "every " + strconv.Itoa(step) + " hours at " + formatList(minutes, func(v int) string { return fmt.Sprintf(":%02d", v) })
+	}
+	return "at " + formatList(minutes, func(v int) string { return fmt.Sprintf(":%02d", v) }) + duringHours(hours)
+}
+
+func duringHours(hours []int) string {
+	prefix := " during hours "
+	if len(hours) == 1 {
+		prefix = " during hour "
+	}
+	return prefix + formatList(hours, func(v int) string { return fmt.Sprintf("%02d", v) })
+}
+
+// evenStep reports the step of sorted values of the form 0, step, 2*step... covering a cycle of size
+func evenStep(values []int, size int) (int, bool) {
+	if len(values) < 2 || values[0] != 0 {
+		return 0, false
+	}
+	step := values[1]
+	if step == 0 || size%step != 0 || len(values) != size/step {
+		return 0, false
+	}
+	for i, v := range values {
+		if v != i*step {
+			return 0, false
+		}
+	}
+	return step, true
+}
+
+// formatList joins sorted values, collapsing runs of three or more into ranges such as Mon–Fri
+func formatList(values []int, name func(int) string) string {
+	var items []string
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			items = append(items, name(values[i])+"–"+name(values[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				items = append(items, name(values[k]))
+			}
+		}
+		i = j + 1
+	}
+	return strings.Join(items, ", ")
+}
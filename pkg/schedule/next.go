@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+// searchHorizon bounds the search for fire times of timings that rarely or never match,
+// such as February 30 or years in the past
+const searchHorizon = 30 * 365 * 24 * time.Hour
+
+// Matches reports whether Cronicle fires an event with the timing at the minute of t. Like
+// Cronicle, the minute is matched on the wall clock of t's location.
+func Matches(timing cronicle_client.CronicleTiming, t time.Time) bool {
+	return dayMatches(timing, t) && contains(timing.Hours, t.Hour()) && contains(timing.Minutes, t.Minute())
+}
+
+// Next returns up to n times after the given time at which Cronicle fires an event with the
+// timing in the location.
+//
+// Cronicle checks every minute of real time against the wall clock of the event's timezone.
+// Wall clock times skipped by a daylight saving gap therefore never fire, and those repeated
+// by an overlap fire twice, once for each offset.
+func Next(timing cronicle_client.CronicleTiming, loc *time.Location, after time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	var times []time.Time
+	walk(timing, loc, after, after.Add(searchHorizon), func(t time.Time) bool {
+		times = append(times, t)
+		return len(times) < n
+	})
+	return times
+}
+
+// Last returns the latest time after the first and no later than the second given time at
+// which Cronicle fires an event with the timing in the location, and false if there is none.
+func Last(timing cronicle_client.CronicleTiming, loc *time.Location, after, until time.Time) (time.Time, bool) {
+	// Searching forward from the start of a long range is slow for frequent timings, so the
+	// range is widened step by step from its end
+	for _, window := range []time.Duration{24 * time.Hour, 366 * 24 * time.Hour, searchHorizon} {
+		from := until.Add(-window)
+		if from.Before(after) {
+			from = after
+		}
+		var last time.Time
+		found := false
+		walk(timing, loc, from, until, func(t time.Time) bool {
+			last, found = t, true
+			return true
+		})
+		if found || from.Equal(after) {
+			return last, found
+		}
+	}
+	return time.Time{}, false
+}
+
+// walk calls fire for every minute after the given time and no later than end at which the
+// timing fires, until fire returns false
+func walk(timing cronicle_client.CronicleTiming, loc *time.Location, after, end time.Time, fire func(time.Time) bool) {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	for !t.After(end) {
+		switch {
+		case !dayMatches(timing, t):
+			t = skipTo(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case !contains(timing.Hours, t.Hour()):
+			t = skipTo(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		default:
+			if contains(timing.Minutes, t.Minute()) && !fire(t) {
+				return
+			}
+			t = t.Add(time.Minute)
+		}
+	}
+}
+
+// skipTo moves to the start of the next day or hour built with time.Date. Inside a daylight
+// saving transition time.Date may pick either instant of an ambiguous wall clock time, so the
+// first one is searched for, and a time before t is never returned.
+func skipTo(t, next time.Time) time.Time {
+	for _, shift := range []time.Duration{time.Hour, 30 * time.Minute} {
+		earlier := next.Add(-shift)
+		if earlier.After(t) && sameWallClock(earlier, next) {
+			next = earlier
+		}
+	}
+	if !next.After(t) {
+		return t.Add(time.Minute)
+	}
+	return next
+}
+
+func sameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd && a.Hour() == b.Hour() && a.Minute() == b.Minute()
+}
+
+func dayMatches(timing cronicle_client.CronicleTiming, t time.Time) bool {
+	return contains(timing.Years, t.Year()) &&
+		contains(timing.Months, int(t.Month())) &&
+		contains(timing.Days, t.Day()) &&
+		contains(timing.Weekdays, int(t.Weekday()))
+}
+
+// contains reports whether a timing field allows value, an empty field allows every value
+func contains(values []int, value int) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
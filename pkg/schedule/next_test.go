@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yasinahlattci/cronicle-operator/pkg/cronicle_client"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestNext(t *testing.T) {
+	loc := mustLoad(t, "Europe/Istanbul")
+	after := time.Date(2024, 5, 17, 16, 52, 30, 0, loc) // a Friday
+
+	timing, _ := Parse("*/15 9-17 * * MON-FRI")
+	got := Next(timing, loc, after, 3)
+	want := []time.Time{
+		time.Date(2024, 5, 17, 17, 0, 0, 0, loc),
+		time.Date(2024, 5, 17, 17, 15, 0, 0, loc),
+		time.Date(2024, 5, 17, 17, 30, 0, 0, loc),
+	}
+	assertTimes(t, got, want)
+
+	got = Next(timing, loc, time.Date(2024, 5, 17, 17, 45, 0, 0, loc), 1)
+	assertTimes(t, got, []time.Time{time.Date(2024, 5, 20, 9, 0, 0, 0, loc)})
+}
+
+func TestNextSkipsDaylightSavingGap(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	// Clocks jump from 02:00 to 03:00 on 2024-03-31
+	timing := cronicle_client.CronicleTiming{Minutes: []int{30}, Hours: []int{2}}
+	got := Next(timing, loc, time.Date(2024, 3, 30, 12, 0, 0, 0, loc), 2)
+	assertTimes(t, got, []time.Time{
+		time.Date(2024, 4, 1, 2, 30, 0, 0, loc),
+		time.Date(2024, 4, 2, 2, 30, 0, 0, loc),
+	})
+}
+
+func TestNextFiresTwiceInDaylightSavingOverlap(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	// Clocks fall back from 03:00 to 02:00 on 2024-10-27
+	timing := cronicle_client.CronicleTiming{Minutes: []int{30}, Hours: []int{2}}
+	got := Next(timing, loc, time.Date(2024, 10, 27, 0, 0, 0, 0, loc), 3)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 times, got %v", got)
+	}
+	if got[1].Sub(got[0]) != time.Hour {
+		t.Fatalf("expected 02:30 to fire in both offsets, got %v", got)
+	}
+	if got[2].Day() != 28 {
+		t.Fatalf("expected the third run on October 28, got %v", got[2])
+	}
+}
+
+func TestNextGivesUpOnImpossibleTimings(t *testing.T) {
+	timing := cronicle_client.CronicleTiming{Days: []int{30}, Months: []int{2}}
+	if got := Next(timing, time.UTC, time.Now(), 1); len(got) != 0 {
+		t.Fatalf("expected no run on February 30, got %v", got)
+	}
+}
+
+func TestLast(t *testing.T) {
+	loc := mustLoad(t, "Europe/Istanbul")
+	after := time.Date(2024, 5, 1, 0, 0, 0, 0, loc)
+
+	timing, _ := Parse("*/15 9-17 * * MON-FRI")
+	got, ok := Last(timing, loc, after, time.Date(2024, 5, 20, 8, 59, 0, 0, loc)) // a Monday
+	if !ok || !got.Equal(time.Date(2024, 5, 17, 17, 45, 0, 0, loc)) {
+		t.Errorf("Last() = %v, %v", got, ok)
+	}
+
+	got, ok = Last(timing, loc, after, time.Date(2024, 5, 20, 9, 0, 0, 0, loc))
+	if !ok || !got.Equal(time.Date(2024, 5, 20, 9, 0, 0, 0, loc)) {
+		t.Errorf("Last() = %v, %v, want the end of the range", got, ok)
+	}
+
+	yearly, _ := Parse("@yearly")
+	if got, ok := Last(yearly, loc, after, time.Date(2024, 12, 31, 23, 59, 0, 0, loc)); ok {
+		t.Errorf("Last() = %v, want no fire time after the start of the range", got)
+	}
+	got, ok = Last(yearly, loc, time.Date(2020, 6, 1, 0, 0, 0, 0, loc), time.Date(2024, 12, 31, 23, 59, 0, 0, loc))
+	if !ok || !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("Last() = %v, %v", got, ok)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := map[string]string{
+		"* * * * *":               "every minute",
+		"*/5 * * * 1-5":           "every 5 minutes, Mon–Fri",
+		"30 2 1 * *":              "at 02:30, on day 1 of the month",
+		"15 9,17 * * *":           "at 09:15, 17:15",
+		"5 * * * *":               "at :05 past every hour",
+		"0,30 9-17 * * *":         "every 30 minutes during hours 09–17",
+		"0 0 * * 0,6":             "at 00:00, Sun, Sat",
+		"0 0 1 1,7 *":             "at 00:00, on day 1 of the month, in Jan, Jul",
+		"10 0,4,8,12,16,20 * * *": "every 4 hours at :10",
+	}
+	for expr, want := range tests {
+		timing, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		if got := Describe(timing); got != want {
+			t.Errorf("Describe(%q) = %q, want %q", expr, got, want)
+		}
+	}
+}
+
+func assertTimes(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}